@@ -0,0 +1,129 @@
+// Package analyze inspects inputs and chunking parameters and reports how
+// well they are likely to deduplicate.
+package analyze
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/AumSahayata/cdcgo/chunk"
+	"github.com/AumSahayata/cdcgo/fastcdc"
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// HighEntropyThreshold is the Shannon entropy, in bits per byte, above which
+// a chunk is treated as compressed or encrypted data. Such chunks only dedupe
+// against exact copies; any upstream change re-randomizes them.
+const HighEntropyThreshold = 7.5
+
+// Entropy returns the Shannon entropy of data in bits per byte (0 to 8).
+func Entropy(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+
+	n := float64(len(data))
+	var e float64
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		e -= p * math.Log2(p)
+	}
+	return e
+}
+
+// Region is a run of consecutive high-entropy chunks.
+type Region struct {
+	Offset int64
+	Size   int64
+}
+
+// EntropyReport summarizes how much of an input is high-entropy.
+//
+// Fields:
+//   - TotalBytes:       input length
+//   - HighEntropyBytes: bytes in chunks above HighEntropyThreshold
+//   - Regions:          high-entropy runs, adjacent chunks merged
+//   - Recommendations:  human-readable advice derived from the above
+type EntropyReport struct {
+	TotalBytes       int64
+	HighEntropyBytes int64
+	Regions          []Region
+	Recommendations  []string
+}
+
+// UndedupableFraction returns HighEntropyBytes / TotalBytes.
+func (r EntropyReport) UndedupableFraction() float64 {
+	if r.TotalBytes == 0 {
+		return 0
+	}
+	return float64(r.HighEntropyBytes) / float64(r.TotalBytes)
+}
+
+// AnalyzeEntropy chunks r with params and classifies each chunk by entropy,
+// reporting which fraction of the input is unlikely to ever dedupe across
+// versions and what to change about it.
+// Returns an error if params are invalid or r fails.
+func AnalyzeEntropy(r io.Reader, params fastcdc.Params) (EntropyReport, error) {
+	var rep EntropyReport
+
+	if err := params.Validate(); err != nil {
+		return rep, fmt.Errorf("analyze entropy: %w", err)
+	}
+
+	in := chunk.NewIngestor(sha256.New(), params.MaxSize, fastcdc.NewChunker(params), func(ch types.Chunk, data []byte) error {
+		rep.TotalBytes += int64(ch.Size)
+		if Entropy(data) < HighEntropyThreshold {
+			return nil
+		}
+
+		rep.HighEntropyBytes += int64(ch.Size)
+		if n := len(rep.Regions); n > 0 && rep.Regions[n-1].Offset+rep.Regions[n-1].Size == ch.Offset {
+			rep.Regions[n-1].Size += int64(ch.Size)
+		} else {
+			rep.Regions = append(rep.Regions, Region{Offset: ch.Offset, Size: int64(ch.Size)})
+		}
+		return nil
+	})
+
+	if _, err := io.Copy(in, r); err != nil {
+		return rep, err
+	}
+	if err := in.Flush(); err != nil {
+		return rep, err
+	}
+
+	rep.Recommendations = recommend(rep)
+	return rep, nil
+}
+
+// recommend derives advice from the entropy figures.
+func recommend(rep EntropyReport) []string {
+	frac := rep.UndedupableFraction()
+
+	switch {
+	case rep.TotalBytes == 0:
+		return nil
+	case frac >= 0.9:
+		return []string{
+			fmt.Sprintf("%.0f%% of the input is high-entropy (compressed or encrypted); it will only dedupe against identical copies", frac*100),
+			"decompress before chunking (chunk.Decompress handles gzip/bzip2) or back up the uncompressed source instead",
+			"if the data must stay compressed, use larger chunks to cut metadata overhead since small chunks gain nothing",
+		}
+	case frac >= 0.3:
+		return []string{
+			fmt.Sprintf("%.0f%% of the input is high-entropy in %d region(s); embedded archives or media limit dedup", frac*100, len(rep.Regions)),
+			"consider storing embedded compressed members uncompressed or chunking them separately",
+		}
+	}
+	return []string{fmt.Sprintf("%.0f%% of the input is high-entropy; no changes recommended", frac*100)}
+}
@@ -0,0 +1,70 @@
+package analyze
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/AumSahayata/cdcgo/datagen"
+	"github.com/AumSahayata/cdcgo/fastcdc"
+)
+
+// TestEntropy checks the extremes of the entropy scale.
+func TestEntropy(t *testing.T) {
+	if e := Entropy(bytes.Repeat([]byte{7}, 1000)); e != 0 {
+		t.Errorf("constant data entropy = %.3f, want 0", e)
+	}
+
+	all := make([]byte, 256*16)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	if e := Entropy(all); e < 7.99 {
+		t.Errorf("uniform data entropy = %.3f, want 8", e)
+	}
+}
+
+// TestAnalyzeEntropy verifies that a compressed region inside text is found.
+func TestAnalyzeEntropy(t *testing.T) {
+	text := bytes.Repeat([]byte("INSERT INTO users VALUES (1, 'alice');\n"), 4000)
+
+	random, _ := datagen.Bytes(datagen.Config{Size: 256 << 10, Seed: 4})
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write(random)
+	_ = zw.Close()
+
+	input := append(append(append([]byte(nil), text...), gz.Bytes()...), text...)
+	params := fastcdc.NewParams(2<<10, 8<<10, 32<<10, nil)
+
+	rep, err := AnalyzeEntropy(bytes.NewReader(input), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rep.TotalBytes != int64(len(input)) {
+		t.Errorf("total = %d, want %d", rep.TotalBytes, len(input))
+	}
+	if len(rep.Regions) != 1 {
+		t.Fatalf("regions = %v, want one compressed region", rep.Regions)
+	}
+
+	// The region must overlap the gzip payload
+	r := rep.Regions[0]
+	gzStart, gzEnd := int64(len(text)), int64(len(text)+gz.Len())
+	if r.Offset >= gzEnd || r.Offset+r.Size <= gzStart {
+		t.Errorf("region %+v does not overlap compressed data [%d,%d)", r, gzStart, gzEnd)
+	}
+
+	frac := rep.UndedupableFraction()
+	if frac < 0.3 || frac > 0.9 {
+		t.Errorf("undedupable fraction = %.2f, want roughly the gzip share", frac)
+	}
+	if len(rep.Recommendations) == 0 {
+		t.Errorf("expected recommendations")
+	}
+
+	if _, err := AnalyzeEntropy(bytes.NewReader(input), fastcdc.Params{}); err == nil {
+		t.Errorf("expected error for invalid params")
+	}
+}
@@ -0,0 +1,193 @@
+package analyze
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/AumSahayata/cdcgo/fastcdc"
+)
+
+// Thresholds used by AnalyzeGear to flag a degenerate gear table.
+const (
+	// MaxBitSkew is the largest tolerated distance from 50% of the share of
+	// entries with a given bit set. A random table of 256 entries stays
+	// within about 0.1.
+	MaxBitSkew = 0.15
+
+	// MaxForcedCutFraction is the largest tolerated share of chunks cut at
+	// MaxSize rather than at a content-defined boundary.
+	MaxForcedCutFraction = 0.5
+)
+
+// SizeStats describes the chunk sizes produced on sample data.
+//
+// Fields:
+//   - Chunks:     number of chunks, excluding the tail
+//   - Mean:       mean chunk size in bytes
+//   - StdDev:     standard deviation of chunk sizes
+//   - ForcedCuts: chunks cut at MaxSize because no boundary was found
+type SizeStats struct {
+	Chunks     int
+	Mean       float64
+	StdDev     float64
+	ForcedCuts int
+}
+
+// GearReport evaluates a gear table.
+//
+// Fields:
+//   - BitBalance:     for each bit, the share of entries with that bit set
+//   - MaxSkew:        largest |BitBalance[i] - 0.5|
+//   - Duplicates:     entries equal to an earlier entry
+//   - MaskCollisions: entries whose bits under Params.Mask equal those of
+//     an earlier entry; these bytes look alike to the cut decision
+//   - Sizes:          chunk sizes on the sample with this table
+//   - Reference:      chunk sizes on the sample with the built-in table
+//   - Warnings:       human-readable problems; empty for a healthy table
+type GearReport struct {
+	BitBalance     [64]float64
+	MaxSkew        float64
+	Duplicates     int
+	MaskCollisions int
+	Sizes          SizeStats
+	Reference      SizeStats
+	Warnings       []string
+}
+
+// AnalyzeGear checks gear for bit bias, duplicate entries, and collisions,
+// and chunks sample with params using both gear and the built-in table to
+// compare the resulting size distributions. It is meant for custom tables
+// generated from seeds, where a weak generator can skew boundaries.
+//
+// Parameters:
+//   - gear:   the table to evaluate
+//   - params: chunking parameters; params.Gear is ignored
+//   - sample: representative input; nil skips the size analysis
+//
+// Returns an error if gear is nil or params are invalid.
+func AnalyzeGear(gear *[256]uint64, params fastcdc.Params, sample []byte) (GearReport, error) {
+	var rep GearReport
+
+	if gear == nil {
+		return rep, errors.New("analyze gear: nil gear table")
+	}
+	if err := params.Validate(); err != nil {
+		return rep, fmt.Errorf("analyze gear: %w", err)
+	}
+
+	for _, v := range gear {
+		for b := range 64 {
+			rep.BitBalance[b] += float64(v >> b & 1)
+		}
+	}
+	for b := range rep.BitBalance {
+		rep.BitBalance[b] /= float64(len(gear))
+		rep.MaxSkew = max(rep.MaxSkew, math.Abs(rep.BitBalance[b]-0.5))
+	}
+
+	seen := make(map[uint64]bool, len(gear))
+	masked := make(map[uint64]bool, len(gear))
+	for _, v := range gear {
+		if seen[v] {
+			rep.Duplicates++
+		}
+		if masked[v&params.Mask] {
+			rep.MaskCollisions++
+		}
+		seen[v] = true
+		masked[v&params.Mask] = true
+	}
+
+	if sample != nil {
+		custom := params
+		custom.Gear = gear
+		rep.Sizes = sizeStats(custom, sample)
+
+		builtin := params
+		builtin.Gear = nil
+		rep.Reference = sizeStats(builtin, sample)
+	}
+
+	rep.Warnings = gearWarnings(rep, params)
+	return rep, nil
+}
+
+// sizeStats chunks sample with params and summarizes the chunk sizes.
+// The final chunk is excluded since its size is set by the input length.
+func sizeStats(params fastcdc.Params, sample []byte) SizeStats {
+	c := fastcdc.NewChunker(params)
+
+	var sizes []int
+	for off := 0; off < len(sample); {
+		cut := c.NextBoundary(sample[off:])
+		off += cut
+		if off < len(sample) {
+			sizes = append(sizes, cut)
+		}
+	}
+
+	st := SizeStats{Chunks: len(sizes)}
+	if len(sizes) == 0 {
+		return st
+	}
+
+	var sum float64
+	for _, n := range sizes {
+		sum += float64(n)
+		if n >= params.MaxSize {
+			st.ForcedCuts++
+		}
+	}
+	st.Mean = sum / float64(len(sizes))
+
+	var sq float64
+	for _, n := range sizes {
+		d := float64(n) - st.Mean
+		sq += d * d
+	}
+	st.StdDev = math.Sqrt(sq / float64(len(sizes)))
+	return st
+}
+
+// gearWarnings derives warnings from the report figures.
+func gearWarnings(rep GearReport, params fastcdc.Params) []string {
+	var w []string
+
+	if rep.Duplicates > 0 {
+		w = append(w, fmt.Sprintf("%d duplicate entries; the generator seed may be degenerate", rep.Duplicates))
+	}
+	if rep.MaxSkew > MaxBitSkew {
+		worst := 0
+		for b := range rep.BitBalance {
+			if math.Abs(rep.BitBalance[b]-0.5) > math.Abs(rep.BitBalance[worst]-0.5) {
+				worst = b
+			}
+		}
+		w = append(w, fmt.Sprintf("bit %d is set in %.0f%% of entries; boundary decisions will be biased", worst, rep.BitBalance[worst]*100))
+	}
+
+	// With k mask bits a random table has about 256²/2^(k+1) collisions
+	if k := bits.OnesCount64(params.Mask); k >= 8 {
+		expected := 256.0 * 256.0 / math.Exp2(float64(k+1))
+		if float64(rep.MaskCollisions) > 4*expected+8 {
+			w = append(w, fmt.Sprintf("%d entries collide under the mask (about %.0f expected)", rep.MaskCollisions, expected))
+		}
+	}
+
+	s, ref := rep.Sizes, rep.Reference
+	if s.Chunks == 0 {
+		return w
+	}
+	if frac := float64(s.ForcedCuts) / float64(s.Chunks); frac > MaxForcedCutFraction {
+		w = append(w, fmt.Sprintf("%.0f%% of chunks were cut at MaxSize; content-defined boundaries are rarely found", frac*100))
+	}
+	if s.StdDev < 0.05*s.Mean {
+		w = append(w, fmt.Sprintf("chunk sizes barely vary (mean %.0f, stddev %.0f); boundaries do not depend on content", s.Mean, s.StdDev))
+	}
+	if ref.Chunks > 0 && (s.Mean < ref.Mean/2 || s.Mean > ref.Mean*2) {
+		w = append(w, fmt.Sprintf("mean chunk size %.0f differs from %.0f with the built-in table", s.Mean, ref.Mean))
+	}
+	return w
+}
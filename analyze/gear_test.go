@@ -0,0 +1,61 @@
+package analyze
+
+import (
+	"testing"
+
+	"github.com/AumSahayata/cdcgo/datagen"
+	"github.com/AumSahayata/cdcgo/fastcdc"
+)
+
+// TestAnalyzeGear checks that a random table passes and that tables built
+// from degenerate seeds are flagged.
+func TestAnalyzeGear(t *testing.T) {
+	sample, _ := datagen.Bytes(datagen.Config{Size: 4 << 20, Seed: 9})
+	params := fastcdc.NewParams(2<<10, 8<<10, 32<<10, nil)
+
+	// A splitmix64 table is a healthy custom table
+	var good [256]uint64
+	x := uint64(42)
+	for i := range good {
+		x += 0x9e3779b97f4a7c15
+		z := (x ^ x>>30) * 0xbf58476d1ce4e5b9
+		z = (z ^ z>>27) * 0x94d049bb133111eb
+		good[i] = z ^ z>>31
+	}
+	if rep, err := AnalyzeGear(&good, params, sample); err != nil || len(rep.Warnings) != 0 {
+		t.Errorf("random table flagged: %v, %v", rep.Warnings, err)
+	}
+
+	// Low bits always zero: every position past AvgSize is a boundary
+	lowZero := good
+	for i := range lowZero {
+		lowZero[i] &^= 0xffff
+	}
+	rep, err := AnalyzeGear(&lowZero, params, sample)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rep.MaxSkew < 0.5 || rep.Sizes.StdDev > 1 {
+		t.Errorf("low-zero table: skew %.2f, size stddev %.0f", rep.MaxSkew, rep.Sizes.StdDev)
+	}
+	if len(rep.Warnings) < 2 {
+		t.Errorf("low-zero table: warnings = %v", rep.Warnings)
+	}
+
+	// A stuck generator repeats one value
+	var stuck [256]uint64
+	for i := range stuck {
+		stuck[i] = good[0]
+	}
+	if rep, err := AnalyzeGear(&stuck, params, nil); err != nil || rep.Duplicates != 255 || len(rep.Warnings) == 0 {
+		t.Errorf("stuck table: duplicates %d, warnings %v, err %v", rep.Duplicates, rep.Warnings, err)
+	}
+
+	// Invalid input is an error, not a panic
+	if _, err := AnalyzeGear(nil, params, sample); err == nil {
+		t.Error("expected error for nil gear table")
+	}
+	if _, err := AnalyzeGear(&good, fastcdc.Params{}, sample); err == nil {
+		t.Error("expected error for invalid params")
+	}
+}
@@ -0,0 +1,294 @@
+// Package bench is a configurable end-to-end benchmark harness.
+//
+// It runs every combination of chunking parameters, hash algorithms, index
+// backends, and worker counts over a reproducible datagen stream, and
+// reports dedup ratio, throughput, and allocations as JSON or CSV.
+package bench
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AumSahayata/cdcgo/chunk"
+	"github.com/AumSahayata/cdcgo/datagen"
+	"github.com/AumSahayata/cdcgo/fastcdc"
+	"github.com/AumSahayata/cdcgo/storage"
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// Index backends understood by Matrix.Backends.
+const (
+	BackendMemory = "memory" // storage.MemoryIndex
+	BackendJSON   = "json"   // storage.PersistentIndexJSON in a temp dir
+)
+
+// Matrix describes the benchmark dimensions. Every combination of Params,
+// Hashes, Backends, and Workers is run once over the same input.
+//
+// Fields:
+//   - Data:     input stream description (see datagen.Config)
+//   - Params:   chunking parameter sets to compare
+//   - Hashes:   hash algorithm names (see storage.NewHasher)
+//   - Backends: index backends (BackendMemory, BackendJSON)
+//   - Workers:  number of concurrent chunking goroutines; the input is
+//     split into that many contiguous segments sharing one ChunkWriter
+type Matrix struct {
+	Data     datagen.Config
+	Params   []fastcdc.Params
+	Hashes   []string
+	Backends []string
+	Workers  []int
+}
+
+// Result holds the measurements of one matrix cell.
+type Result struct {
+	MinSize      int
+	AvgSize      int
+	MaxSize      int
+	Hash         string
+	Backend      string
+	Workers      int
+	InputBytes   int64
+	Chunks       int
+	UniqueChunks int
+	StoredBytes  int64
+	DedupRatio   float64 // InputBytes / StoredBytes
+	MBPerSec     float64
+	Duration     time.Duration
+	Allocs       uint64
+	AllocBytes   uint64
+}
+
+// Run executes every cell of the matrix and returns one Result per cell.
+func Run(m Matrix) ([]Result, error) {
+	data, err := datagen.Bytes(m.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, params := range m.Params {
+		for _, hashName := range m.Hashes {
+			for _, backend := range m.Backends {
+				for _, workers := range m.Workers {
+					r, err := runCell(data, params, hashName, backend, workers)
+					if err != nil {
+						return results, err
+					}
+					results = append(results, r)
+				}
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// runCell chunks and dedupes data once with the given settings.
+func runCell(data []byte, params fastcdc.Params, hashName, backend string, workers int) (Result, error) {
+	if workers < 1 {
+		return Result{}, fmt.Errorf("bench: workers must be >= 1, got %d", workers)
+	}
+	if err := params.Validate(); err != nil {
+		return Result{}, fmt.Errorf("bench: %w", err)
+	}
+	if _, err := storage.NewHasher(hashName); err != nil {
+		return Result{}, err
+	}
+
+	idx, cleanup, err := newIndex(backend)
+	if err != nil {
+		return Result{}, err
+	}
+	defer cleanup()
+
+	r := Result{
+		MinSize:    params.MinSize,
+		AvgSize:    params.AvgSize,
+		MaxSize:    params.MaxSize,
+		Hash:       hashName,
+		Backend:    backend,
+		Workers:    workers,
+		InputBytes: int64(len(data)),
+	}
+
+	var stored counter
+	cw := chunk.NewChunkWriter(io.Discard, idx)
+	cw.SetObserver(&stored)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	seg := (len(data) + workers - 1) / workers
+	for w := range workers {
+		lo := min(w*seg, len(data))
+		hi := min(lo+seg, len(data))
+
+		wg.Add(1)
+		go func(part []byte) {
+			defer wg.Done()
+			errs <- chunkSegment(part, params, hashName, cw)
+		}(data[lo:hi])
+	}
+	wg.Wait()
+	close(errs)
+
+	r.Duration = time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	for err := range errs {
+		if err != nil {
+			return r, err
+		}
+	}
+
+	r.Chunks, r.UniqueChunks, r.StoredBytes = stored.totals()
+	if r.StoredBytes > 0 {
+		r.DedupRatio = float64(r.InputBytes) / float64(r.StoredBytes)
+	}
+	if secs := r.Duration.Seconds(); secs > 0 {
+		r.MBPerSec = float64(r.InputBytes) / (1 << 20) / secs
+	}
+	r.Allocs = after.Mallocs - before.Mallocs
+	r.AllocBytes = after.TotalAlloc - before.TotalAlloc
+
+	return r, nil
+}
+
+// chunkSegment chunks part and writes every chunk through cw.
+func chunkSegment(part []byte, params fastcdc.Params, hashName string, cw *chunk.ChunkWriter) error {
+	hasher, err := storage.NewHasher(hashName)
+	if err != nil {
+		return err
+	}
+
+	cr := chunk.NewChunkReader(bytes.NewReader(part), hasher, params.MaxSize, fastcdc.NewChunker(params))
+	for {
+		ch, err := cr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, _, err := cw.WriteChunk(ch, part[ch.Offset:ch.Offset+int64(ch.Size)]); err != nil {
+			return err
+		}
+	}
+}
+
+// newIndex creates the index for a backend name and a cleanup function.
+func newIndex(backend string) (storage.Index, func(), error) {
+	switch backend {
+	case BackendMemory:
+		return storage.NewMemoryIndex(), func() {}, nil
+	case BackendJSON:
+		dir, err := os.MkdirTemp("", "cdcgo-bench-")
+		if err != nil {
+			return nil, nil, err
+		}
+		idx, err := storage.NewPersistentIndexJSON(filepath.Join(dir, "index.json"))
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, nil, err
+		}
+		return idx, func() { os.RemoveAll(dir) }, nil
+	}
+	return nil, nil, fmt.Errorf("bench: unknown backend %q", backend)
+}
+
+// counter is a chunk.Observer tallying written, duplicate, and zero chunks.
+type counter struct {
+	chunk.NopObserver
+	mu          sync.Mutex
+	chunks      int
+	unique      int
+	storedBytes int64
+}
+
+func (c *counter) OnChunkStored(_ types.Chunk, n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.chunks++
+	c.unique++
+	c.storedBytes += int64(n)
+}
+
+func (c *counter) OnDuplicate(types.Chunk) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.chunks++
+}
+
+func (c *counter) OnZeroChunk(types.Chunk) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.chunks++
+}
+
+func (c *counter) totals() (chunks, unique int, storedBytes int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.chunks, c.unique, c.storedBytes
+}
+
+// WriteJSON writes results as an indented JSON array.
+func WriteJSON(w io.Writer, results []Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	return enc.Encode(results)
+}
+
+// csvHeader lists the CSV columns written by WriteCSV.
+var csvHeader = []string{
+	"min_size", "avg_size", "max_size", "hash", "backend", "workers",
+	"input_bytes", "chunks", "unique_chunks", "stored_bytes",
+	"dedup_ratio", "mb_per_sec", "duration_ns", "allocs", "alloc_bytes",
+}
+
+// WriteCSV writes results as CSV with a header row.
+func WriteCSV(w io.Writer, results []Result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		row := []string{
+			strconv.Itoa(r.MinSize),
+			strconv.Itoa(r.AvgSize),
+			strconv.Itoa(r.MaxSize),
+			r.Hash,
+			r.Backend,
+			strconv.Itoa(r.Workers),
+			strconv.FormatInt(r.InputBytes, 10),
+			strconv.Itoa(r.Chunks),
+			strconv.Itoa(r.UniqueChunks),
+			strconv.FormatInt(r.StoredBytes, 10),
+			strconv.FormatFloat(r.DedupRatio, 'f', 4, 64),
+			strconv.FormatFloat(r.MBPerSec, 'f', 2, 64),
+			strconv.FormatInt(int64(r.Duration), 10),
+			strconv.FormatUint(r.Allocs, 10),
+			strconv.FormatUint(r.AllocBytes, 10),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
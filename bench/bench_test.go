@@ -0,0 +1,96 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+
+	"github.com/AumSahayata/cdcgo/datagen"
+	"github.com/AumSahayata/cdcgo/fastcdc"
+)
+
+// smallMatrix is a quick matrix covering every dimension.
+func smallMatrix() Matrix {
+	return Matrix{
+		Data: datagen.Config{Size: 512 << 10, BlockSize: 8 << 10, DupRatio: 0.5, Seed: 3},
+		Params: []fastcdc.Params{
+			fastcdc.NewParams(1<<10, 4<<10, 16<<10, nil),
+			fastcdc.NewParams(2<<10, 8<<10, 32<<10, nil),
+		},
+		Hashes:   []string{"sha1", "sha256"},
+		Backends: []string{BackendMemory, BackendJSON},
+		Workers:  []int{1, 2},
+	}
+}
+
+// TestRun_Matrix verifies that every cell is run and reports sane numbers.
+func TestRun_Matrix(t *testing.T) {
+	m := smallMatrix()
+	results, err := Run(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := 2 * 2 * 2 * 2; len(results) != want {
+		t.Fatalf("results = %d, want %d", len(results), want)
+	}
+
+	for _, r := range results {
+		if r.Chunks == 0 || r.UniqueChunks > r.Chunks {
+			t.Errorf("bad chunk counts: %+v", r)
+		}
+		if r.StoredBytes > r.InputBytes {
+			t.Errorf("stored more than input: %+v", r)
+		}
+		// Half of the blocks repeat, so dedup must find something
+		if r.DedupRatio <= 1 {
+			t.Errorf("expected dedup ratio > 1, got %.3f", r.DedupRatio)
+		}
+	}
+}
+
+// TestRun_UnknownBackend verifies that invalid dimensions are rejected.
+func TestRun_UnknownBackend(t *testing.T) {
+	m := smallMatrix()
+	m.Backends = []string{"tape"}
+	if _, err := Run(m); err == nil {
+		t.Errorf("expected error for unknown backend")
+	}
+}
+
+// TestRun_InvalidParams verifies that invalid chunking params are rejected
+// before any chunking starts.
+func TestRun_InvalidParams(t *testing.T) {
+	m := smallMatrix()
+	m.Params = []fastcdc.Params{{}}
+	if _, err := Run(m); err == nil {
+		t.Errorf("expected error for invalid params")
+	}
+}
+
+// TestWriteJSONAndCSV verifies both output formats.
+func TestWriteJSONAndCSV(t *testing.T) {
+	results := []Result{{MinSize: 1, AvgSize: 2, MaxSize: 4, Hash: "sha256", Backend: BackendMemory, Workers: 1}}
+
+	var jbuf bytes.Buffer
+	if err := WriteJSON(&jbuf, results); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var decoded []Result
+	if err := json.Unmarshal(jbuf.Bytes(), &decoded); err != nil || len(decoded) != 1 {
+		t.Errorf("JSON round-trip failed: %v, %v", err, decoded)
+	}
+
+	var cbuf bytes.Buffer
+	if err := WriteCSV(&cbuf, results); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	rows, err := csv.NewReader(&cbuf).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV parse failed: %v", err)
+	}
+	if len(rows) != 2 || len(rows[1]) != len(csvHeader) {
+		t.Errorf("unexpected CSV shape: %v", rows)
+	}
+}
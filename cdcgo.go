@@ -0,0 +1,150 @@
+// Package cdcgo provides top-level helpers built on the chunk and fastcdc
+// packages.
+package cdcgo
+
+import (
+	"crypto/sha256"
+	"io"
+	"os"
+
+	"github.com/AumSahayata/cdcgo/chunk"
+	"github.com/AumSahayata/cdcgo/fastcdc"
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// Range is a run of identical content found in both inputs.
+//
+// Fields:
+//   - AOffset: start of the run in input A
+//   - BOffset: start of the run in input B
+//   - Size:    length of the run in bytes
+type Range struct {
+	AOffset int64
+	BOffset int64
+	Size    int64
+}
+
+// Comparison reports how much content two inputs share at chunk granularity.
+//
+// Fields:
+//   - SizeA, SizeB: input lengths in bytes
+//   - SharedBytes:  bytes of B whose chunks also occur in A
+//   - UniqueA:      bytes of A whose chunks do not occur in B
+//   - UniqueB:      bytes of B whose chunks do not occur in A
+//   - Matches:      matching ranges in B order, adjacent matches merged
+type Comparison struct {
+	SizeA       int64
+	SizeB       int64
+	SharedBytes int64
+	UniqueA     int64
+	UniqueB     int64
+	Matches     []Range
+}
+
+// Similarity returns SharedBytes / SizeB, the fraction of B that dedupes
+// against A. It is 1 when B is empty.
+func (c Comparison) Similarity() float64 {
+	if c.SizeB == 0 {
+		return 1
+	}
+	return float64(c.SharedBytes) / float64(c.SizeB)
+}
+
+// CompareFiles chunks the files at paths a and b with params and reports
+// shared and unique bytes, without any storage or index.
+func CompareFiles(a, b string, params fastcdc.Params) (Comparison, error) {
+	fa, err := os.Open(a)
+	if err != nil {
+		return Comparison{}, err
+	}
+	defer fa.Close()
+
+	fb, err := os.Open(b)
+	if err != nil {
+		return Comparison{}, err
+	}
+	defer fb.Close()
+
+	return CompareReaders(fa, fb, params)
+}
+
+// CompareReaders is CompareFiles for arbitrary streams.
+// Returns an error if params are invalid (see fastcdc.Params.Validate).
+func CompareReaders(a, b io.Reader, params fastcdc.Params) (Comparison, error) {
+	if err := params.Validate(); err != nil {
+		return Comparison{}, err
+	}
+
+	chunksA, err := readChunks(a, params)
+	if err != nil {
+		return Comparison{}, err
+	}
+	chunksB, err := readChunks(b, params)
+	if err != nil {
+		return Comparison{}, err
+	}
+
+	var c Comparison
+
+	// First occurrence of each chunk in A
+	inA := make(map[string]types.Chunk, len(chunksA))
+	for _, ch := range chunksA {
+		c.SizeA += int64(ch.Size)
+		if _, ok := inA[ch.HexHash()]; !ok {
+			inA[ch.HexHash()] = ch
+		}
+	}
+
+	inB := make(map[string]struct{}, len(chunksB))
+	for _, ch := range chunksB {
+		c.SizeB += int64(ch.Size)
+		inB[ch.HexHash()] = struct{}{}
+
+		match, ok := inA[ch.HexHash()]
+		if !ok || !match.Equal(ch) {
+			c.UniqueB += int64(ch.Size)
+			continue
+		}
+
+		c.SharedBytes += int64(ch.Size)
+		c.Matches = appendRange(c.Matches, Range{AOffset: match.Offset, BOffset: ch.Offset, Size: int64(ch.Size)})
+	}
+
+	for _, ch := range chunksA {
+		if _, ok := inB[ch.HexHash()]; !ok {
+			c.UniqueA += int64(ch.Size)
+		}
+	}
+
+	return c, nil
+}
+
+// appendRange appends r to ranges, merging it into the last range when both
+// sides continue contiguously.
+func appendRange(ranges []Range, r Range) []Range {
+	if n := len(ranges); n > 0 {
+		last := &ranges[n-1]
+		if last.AOffset+last.Size == r.AOffset && last.BOffset+last.Size == r.BOffset {
+			last.Size += r.Size
+			return ranges
+		}
+	}
+	return append(ranges, r)
+}
+
+// readChunks splits r into chunks hashed with SHA-256.
+func readChunks(r io.Reader, params fastcdc.Params) ([]types.Chunk, error) {
+	cr := chunk.NewChunkReader(r, sha256.New(), params.MaxSize, fastcdc.NewChunker(params))
+
+	var chunks []types.Chunk
+	for {
+		ch, err := cr.Next()
+		if err == io.EOF {
+			return chunks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, ch)
+	}
+}
@@ -0,0 +1,80 @@
+package cdcgo
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AumSahayata/cdcgo/datagen"
+	"github.com/AumSahayata/cdcgo/fastcdc"
+)
+
+// TestCompareFiles verifies that a file with a small insertion is reported
+// as mostly shared with its original.
+func TestCompareFiles(t *testing.T) {
+	orig, err := datagen.Bytes(datagen.Config{Size: 256 << 10, Seed: 11})
+	if err != nil {
+		t.Fatalf("datagen failed: %v", err)
+	}
+	edited := append(append(append([]byte(nil), orig[:100<<10]...), []byte("inserted bytes")...), orig[100<<10:]...)
+
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	if err := os.WriteFile(a, orig, 0644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := os.WriteFile(b, edited, 0644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	params := fastcdc.NewParams(1<<10, 4<<10, 16<<10, nil)
+	c, err := CompareFiles(a, b, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if c.SizeA != int64(len(orig)) || c.SizeB != int64(len(edited)) {
+		t.Errorf("sizes = %d/%d, want %d/%d", c.SizeA, c.SizeB, len(orig), len(edited))
+	}
+	if c.SharedBytes+c.UniqueB != c.SizeB {
+		t.Errorf("shared (%d) + uniqueB (%d) != sizeB (%d)", c.SharedBytes, c.UniqueB, c.SizeB)
+	}
+	if c.Similarity() < 0.8 {
+		t.Errorf("similarity = %.2f, want >= 0.8", c.Similarity())
+	}
+
+	// Matching ranges must really hold identical bytes
+	for _, r := range c.Matches {
+		if !bytes.Equal(orig[r.AOffset:r.AOffset+r.Size], edited[r.BOffset:r.BOffset+r.Size]) {
+			t.Errorf("range %+v does not match", r)
+		}
+	}
+	// The insertion splits the match into (at least) two ranges
+	if len(c.Matches) < 2 {
+		t.Errorf("expected at least 2 merged ranges, got %d", len(c.Matches))
+	}
+}
+
+// TestCompareReaders_Disjoint verifies that unrelated inputs share nothing.
+func TestCompareReaders_Disjoint(t *testing.T) {
+	a, _ := datagen.Bytes(datagen.Config{Size: 64 << 10, Seed: 1})
+	b, _ := datagen.Bytes(datagen.Config{Size: 64 << 10, Seed: 2})
+
+	c, err := CompareReaders(bytes.NewReader(a), bytes.NewReader(b), fastcdc.NewParams(1<<10, 4<<10, 16<<10, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.SharedBytes != 0 || c.UniqueA != c.SizeA || c.UniqueB != c.SizeB {
+		t.Errorf("expected no sharing, got %+v", c)
+	}
+}
+
+// TestCompareReaders_InvalidParams verifies that zero-value params are
+// rejected instead of producing empty chunks forever.
+func TestCompareReaders_InvalidParams(t *testing.T) {
+	a := bytes.NewReader([]byte("some data"))
+	if _, err := CompareReaders(a, a, fastcdc.Params{}); err == nil {
+		t.Error("expected error for invalid params")
+	}
+}
@@ -0,0 +1,54 @@
+package chunk
+
+// Chunker finds chunk boundaries. *fastcdc.Chunker implements it, and so
+// can Rabin, Buzhash, or fixed-size chunkers, which then work with
+// ChunkReader, Ingestor, and FanIn unchanged.
+//
+// NextBoundary returns the length of the next chunk at the start of buf,
+// between 1 and len(buf) for a non-empty buf. It is called with at most
+// bufSize bytes (see NewChunkReader) and must only depend on buf, so
+// boundaries are reproducible. Implementations shared by a FanIn must be
+// safe for concurrent use.
+type Chunker interface {
+	NextBoundary(buf []byte) int
+}
+
+// StreamingChunker is an optional extension of Chunker that reports the
+// bytes it scans, so ChunkReader can hash a chunk in the same pass as the
+// boundary search (see fastcdc.Chunker.NextBoundaryFunc). visit must be
+// called with consecutive pieces covering exactly buf[:cut].
+type StreamingChunker interface {
+	Chunker
+	NextBoundaryFunc(buf []byte, visit func(p []byte)) int
+}
+
+// FixedSize is a Chunker that cuts every n bytes. Fixed-size chunks dedupe
+// only aligned, unshifted data, but are cheap and match block-level
+// storage. n <= 0 means the whole buffer is one chunk.
+type FixedSize int
+
+// NextBoundary returns n, or len(buf) if that is shorter.
+func (n FixedSize) NextBoundary(buf []byte) int {
+	if n <= 0 {
+		return len(buf)
+	}
+	return min(int(n), len(buf))
+}
+
+// nextBoundary finds the next boundary with c and passes buf[:cut] to
+// visit, during the scan if c is a StreamingChunker.
+func nextBoundary(c Chunker, buf []byte, visit func(p []byte)) int {
+	if sc, ok := c.(StreamingChunker); ok {
+		return sc.NextBoundaryFunc(buf, visit)
+	}
+
+	cut := clampCut(c.NextBoundary(buf), len(buf))
+	visit(buf[:cut])
+	return cut
+}
+
+// clampCut keeps a boundary from a third-party Chunker within 1..n, so a
+// misbehaving implementation cannot stall the reader with empty chunks.
+func clampCut(cut, n int) int {
+	return min(max(cut, 1), n)
+}
@@ -0,0 +1,72 @@
+package chunk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// zeroChunker always returns an invalid boundary.
+type zeroChunker struct{}
+
+func (zeroChunker) NextBoundary([]byte) int { return 0 }
+
+// TestChunkReader_FixedSize checks that a non-FastCDC Chunker plugs into
+// ChunkReader and Ingestor and yields the same fixed-size chunks.
+func TestChunkReader_FixedSize(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000) // 10000 bytes
+
+	cr := NewChunkReader(bytes.NewReader(data), sha256.New(), 4096, FixedSize(4096))
+	var fromReader []types.Chunk
+	for {
+		ch, err := cr.Next()
+		if err != nil {
+			break
+		}
+		fromReader = append(fromReader, ch)
+	}
+
+	var fromIngestor []types.Chunk
+	in := NewIngestor(sha256.New(), 4096, FixedSize(4096), func(ch types.Chunk, _ []byte) error {
+		fromIngestor = append(fromIngestor, ch)
+		return nil
+	})
+	_, _ = in.Write(data)
+	_ = in.Flush()
+
+	wantSizes := []int{4096, 4096, 1808}
+	for name, chunks := range map[string][]types.Chunk{"reader": fromReader, "ingestor": fromIngestor} {
+		if len(chunks) != len(wantSizes) {
+			t.Fatalf("%s: %d chunks, want %d", name, len(chunks), len(wantSizes))
+		}
+		for i, ch := range chunks {
+			want := sha256.Sum256(data[ch.Offset : ch.Offset+int64(ch.Size)])
+			if ch.Size != wantSizes[i] || !bytes.Equal(ch.Hash, want[:]) {
+				t.Errorf("%s chunk %d: size %d, hash ok %v", name, i, ch.Size, bytes.Equal(ch.Hash, want[:]))
+			}
+		}
+	}
+}
+
+// TestChunkReader_InvalidBoundary checks that a Chunker returning 0 still
+// makes progress.
+func TestChunkReader_InvalidBoundary(t *testing.T) {
+	cr := NewChunkReader(bytes.NewReader([]byte("abc")), sha256.New(), 2, zeroChunker{})
+
+	var total int
+	for range 10 {
+		ch, err := cr.Next()
+		if err != nil {
+			break
+		}
+		if ch.Size == 0 {
+			t.Fatal("empty chunk produced")
+		}
+		total += ch.Size
+	}
+	if total != 3 {
+		t.Errorf("chunked %d bytes, want 3", total)
+	}
+}
@@ -0,0 +1,112 @@
+package chunk
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrUnsupportedCodec is returned by Decompress when the input is
+// recognized as compressed but no decoder is registered for its codec.
+var ErrUnsupportedCodec = errors.New("unsupported compression codec")
+
+// DecoderFunc wraps a compressed stream in a decompressing reader.
+type DecoderFunc func(r io.Reader) (io.Reader, error)
+
+// codec describes a compressed format recognized by its magic bytes.
+type codec struct {
+	name   string
+	magic  []byte
+	rest   func(b byte) bool // optional check of the byte after magic
+	decode DecoderFunc       // nil if recognized but not decodable
+}
+
+// matches reports whether head, the first bytes of the input, starts with
+// the codec's magic.
+func (c codec) matches(head []byte) bool {
+	if !bytes.HasPrefix(head, c.magic) {
+		return false
+	}
+	if c.rest == nil {
+		return true
+	}
+	return len(head) > len(c.magic) && c.rest(head[len(c.magic)])
+}
+
+// peekSize is how many leading bytes Decompress inspects.
+const peekSize = 16
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = []codec{
+		// gzip: ID1, ID2, and CM 8 (deflate), the only method defined
+		{name: "gzip", magic: []byte{0x1f, 0x8b, 0x08}, decode: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		// bzip2: "BZh" followed by the block size digit '1'..'9'
+		{name: "bzip2", magic: []byte("BZh"), rest: func(b byte) bool { return b >= '1' && b <= '9' },
+			decode: func(r io.Reader) (io.Reader, error) { return bzip2.NewReader(r), nil }},
+		{name: "zstd", magic: []byte{0x28, 0xb5, 0x2f, 0xfd}},
+		{name: "xz", magic: []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	}
+)
+
+// RegisterDecoder registers (or replaces) the decoder for a codec identified
+// by its magic bytes (at most 16). gzip and bzip2 are built in; zstd and xz
+// are recognized but need a decoder registered by the application, e.g.
+//
+//	chunk.RegisterDecoder("zstd", []byte{0x28, 0xb5, 0x2f, 0xfd}, func(r io.Reader) (io.Reader, error) {
+//		return zstd.NewReader(r)
+//	})
+func RegisterDecoder(name string, magic []byte, decode DecoderFunc) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	for i := range codecs {
+		if codecs[i].name == name {
+			codecs[i] = codec{name: name, magic: magic, decode: decode}
+			return
+		}
+	}
+	codecs = append(codecs, codec{name: name, magic: magic, decode: decode})
+}
+
+// Decompress detects compressed input by its magic bytes and returns a
+// reader over the logical (decompressed) content, so the chunker sees data
+// that can dedupe across versions. Uncompressed input is passed through.
+//
+// It returns the reader, the detected codec name ("" if uncompressed), and
+// an error wrapping ErrUnsupportedCodec if the codec is recognized but no
+// decoder is registered. Typical use:
+//
+//	src, _, err := chunk.Decompress(f)
+//	if err != nil { ... }
+//	cr := chunk.NewChunkReader(src, sha256.New(), params.MaxSize, chunker)
+func Decompress(r io.Reader) (io.Reader, string, error) {
+	br := bufio.NewReader(r)
+
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	head, _ := br.Peek(peekSize)
+	for _, c := range codecs {
+		if !c.matches(head) {
+			continue
+		}
+		if c.decode == nil {
+			return nil, c.name, fmt.Errorf("%w: input is %s compressed, but no %s decoder is registered (see RegisterDecoder)",
+				ErrUnsupportedCodec, c.name, c.name)
+		}
+
+		dr, err := c.decode(br)
+		if err != nil {
+			return nil, c.name, fmt.Errorf("open %s stream: %w", c.name, err)
+		}
+		return dr, c.name, nil
+	}
+
+	return br, "", nil
+}
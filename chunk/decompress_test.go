@@ -0,0 +1,152 @@
+package chunk
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/AumSahayata/cdcgo/datagen"
+	"github.com/AumSahayata/cdcgo/fastcdc"
+)
+
+// TestDecompress_Gzip verifies that gzip input is transparently decompressed.
+func TestDecompress_Gzip(t *testing.T) {
+	plain := bytes.Repeat([]byte("logical content "), 1000)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write(plain)
+	_ = zw.Close()
+
+	r, name, err := Decompress(&gz)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "gzip" {
+		t.Errorf("codec = %q, want gzip", name)
+	}
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Errorf("decompressed content mismatch")
+	}
+}
+
+// TestDecompress_Chunks verifies that chunking a decompressed gzip stream,
+// which delivers at most 32 KiB per Read, gives the same chunks as
+// chunking the raw data.
+func TestDecompress_Chunks(t *testing.T) {
+	data, err := datagen.Bytes(datagen.Config{Size: 4 << 20, Seed: 21})
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := fastcdc.NewParams(16<<10, 64<<10, 256<<10, nil)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write(data)
+	_ = zw.Close()
+
+	src, _, err := Decompress(&gz)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cr := NewChunkReader(src, sha256.New(), params.MaxSize, fastcdc.NewChunker(params))
+
+	want := collectChunks(t, data, params)
+	for i := 0; ; i++ {
+		got, err := cr.Next()
+		if err == io.EOF {
+			if i != len(want) {
+				t.Errorf("got %d chunks, want %d", i, len(want))
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if i >= len(want) || got.Offset != want[i].Offset || !got.Equal(want[i]) {
+			t.Fatalf("chunk %d = %v, differs from raw chunking", i, got)
+		}
+	}
+}
+
+// TestDecompress_Plain verifies that uncompressed input passes through,
+// including inputs shorter than the longest magic.
+func TestDecompress_Plain(t *testing.T) {
+	for _, plain := range [][]byte{[]byte("plain text input"), []byte("x"), nil} {
+		r, name, err := Decompress(bytes.NewReader(plain))
+		if err != nil || name != "" {
+			t.Fatalf("unexpected result: %q, %v", name, err)
+		}
+		got, _ := io.ReadAll(r)
+		if !bytes.Equal(got, plain) {
+			t.Errorf("content mismatch: got %q, want %q", got, plain)
+		}
+	}
+}
+
+// TestDecompress_Magic verifies that near-miss headers pass through as
+// plain data and that zstd and xz report a clear unsupported error.
+func TestDecompress_Magic(t *testing.T) {
+	for _, plain := range [][]byte{
+		[]byte("BZhello, not bzip2"), // no block size digit
+		[]byte("BZh0"),               // block size out of range
+		{0x1f, 0x8b, 0x00, 'x', 'y'}, // gzip ID with unknown method
+		{0x1f, 0x8b},                 // truncated gzip header
+	} {
+		r, name, err := Decompress(bytes.NewReader(plain))
+		if err != nil || name != "" {
+			t.Errorf("%q: unexpected result: %q, %v", plain, name, err)
+			continue
+		}
+		if got, _ := io.ReadAll(r); !bytes.Equal(got, plain) {
+			t.Errorf("%q: content mismatch: got %q", plain, got)
+		}
+	}
+
+	for name, input := range map[string][]byte{
+		"zstd": {0x28, 0xb5, 0x2f, 0xfd, 0x00},
+		"xz":   {0xfd, '7', 'z', 'X', 'Z', 0x00, 0x00},
+	} {
+		_, got, err := Decompress(bytes.NewReader(input))
+		if !errors.Is(err, ErrUnsupportedCodec) || got != name {
+			t.Errorf("%s: got %q, %v; want ErrUnsupportedCodec", name, got, err)
+		} else if !strings.Contains(err.Error(), "no "+name+" decoder") {
+			t.Errorf("%s: unclear error %q", name, err)
+		}
+	}
+}
+
+// TestDecompress_RegisterDecoder verifies that recognized codecs without a
+// decoder fail, and succeed once a decoder is registered.
+func TestDecompress_RegisterDecoder(t *testing.T) {
+	magic := []byte{0x28, 0xb5, 0x2f, 0xfd}
+	input := append(append([]byte(nil), magic...), []byte("payload")...)
+
+	if _, _, err := Decompress(bytes.NewReader(input)); !errors.Is(err, ErrUnsupportedCodec) {
+		t.Fatalf("expected ErrUnsupportedCodec, got %v", err)
+	}
+
+	// Fake decoder: strip the magic
+	RegisterDecoder("zstd", magic, func(r io.Reader) (io.Reader, error) {
+		_, err := io.CopyN(io.Discard, r, int64(len(magic)))
+		return r, err
+	})
+	defer RegisterDecoder("zstd", magic, nil)
+
+	r, name, err := Decompress(bytes.NewReader(input))
+	if err != nil || name != "zstd" {
+		t.Fatalf("unexpected result: %q, %v", name, err)
+	}
+	if got, _ := io.ReadAll(r); string(got) != "payload" {
+		t.Errorf("content = %q, want payload", got)
+	}
+}
@@ -0,0 +1,44 @@
+package chunk
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// SourceSize returns the size in bytes of the data behind f.
+//
+// For regular files it is the Stat size. Block devices (/dev/sdX) and some
+// special files report a size of zero from Stat, so for those the size is
+// discovered by seeking to the end; the original position is restored
+// afterwards. Streams that cannot seek (pipes, sockets) return -1 and a
+// nil error, meaning the size is unknown.
+//
+// The result is intended for progress reporting: compare it with
+// chunk.Offset + int64(chunk.Size) as chunks are produced.
+func SourceSize(f *os.File) (int64, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("stat source: %w", err)
+	}
+	if fi.Mode().IsRegular() || fi.Size() > 0 {
+		return fi.Size(), nil
+	}
+	if fi.Mode()&(os.ModeNamedPipe|os.ModeSocket) != 0 {
+		return -1, nil
+	}
+
+	cur, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1, nil
+	}
+	end, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return -1, nil
+	}
+	if _, err := f.Seek(cur, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("restore source position: %w", err)
+	}
+
+	return end, nil
+}
@@ -0,0 +1,56 @@
+package chunk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSourceSize_RegularFile verifies that regular files report their size
+// and that the read position is left untouched.
+func TestSourceSize_RegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "disk.img")
+	if err := os.WriteFile(path, make([]byte, 12345), 0644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(100, 0); err != nil {
+		t.Fatalf("seek failed: %v", err)
+	}
+
+	size, err := SourceSize(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if size != 12345 {
+		t.Errorf("size = %d, want 12345", size)
+	}
+
+	if pos, _ := f.Seek(0, 1); pos != 100 {
+		t.Errorf("position moved to %d, want 100", pos)
+	}
+}
+
+// TestSourceSize_Pipe verifies that unseekable streams report -1.
+func TestSourceSize_Pipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Skipf("pipes unavailable: %v", err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	size, err := SourceSize(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if size != -1 {
+		t.Errorf("size = %d, want -1", size)
+	}
+}
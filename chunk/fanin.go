@@ -0,0 +1,75 @@
+package chunk
+
+import (
+	"context"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// FanIn ingests many input streams concurrently (e.g. files uploaded to a
+// dedup service at the same time) into one shared ChunkWriter, so all
+// streams dedupe against the same index and storage.
+//
+// At most maxStreams streams are chunked at once; further Ingest calls wait
+// for a free slot. FanIn is safe for concurrent use.
+type FanIn struct {
+	cw      *ChunkWriter     // shared destination and index
+	chunker Chunker          // shared by all streams
+	bufSize int              // maximum chunk size
+	newHash func() hash.Hash // per-stream hasher constructor
+	slots   chan struct{}    // concurrency limit
+}
+
+// NewFanIn creates a FanIn.
+//
+// Parameters:
+//   - cw: the shared ChunkWriter all streams store into
+//   - chunker: the boundary finder, safe for concurrent use
+//   - bufSize: the maximum chunk size in bytes (typically params.MaxSize)
+//   - newHash: creates the hasher for each stream (e.g. sha256.New)
+//   - maxStreams: how many streams may be chunked at once; <= 0 means 1
+func NewFanIn(cw *ChunkWriter, chunker Chunker, bufSize int, newHash func() hash.Hash, maxStreams int) *FanIn {
+	return &FanIn{
+		cw:      cw,
+		chunker: chunker,
+		bufSize: bufSize,
+		newHash: newHash,
+		slots:   make(chan struct{}, max(maxStreams, 1)),
+	}
+}
+
+// Ingest chunks r, stores its unique chunks through the shared ChunkWriter,
+// and returns the stream's chunks in order: the list needed to reassemble
+// it later. It waits for a free slot first; ctx cancels the wait and stops
+// ingestion between chunks.
+func (f *FanIn) Ingest(ctx context.Context, r io.Reader) ([]types.Chunk, error) {
+	select {
+	case f.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-f.slots }()
+
+	var chunks []types.Chunk
+	in := NewIngestor(f.newHash(), f.bufSize, f.chunker, func(ch types.Chunk, data []byte) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, _, err := f.cw.WriteChunk(ch, data); err != nil {
+			return fmt.Errorf("write chunk %s: %w", ch.HexHash(), err)
+		}
+		chunks = append(chunks, ch)
+		return nil
+	})
+
+	if _, err := io.Copy(in, r); err != nil {
+		return nil, fmt.Errorf("ingest: %w", err)
+	}
+	if err := in.Flush(); err != nil {
+		return nil, fmt.Errorf("ingest: %w", err)
+	}
+	return chunks, nil
+}
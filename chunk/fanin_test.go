@@ -0,0 +1,96 @@
+package chunk
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/AumSahayata/cdcgo/datagen"
+	"github.com/AumSahayata/cdcgo/fastcdc"
+	"github.com/AumSahayata/cdcgo/storage"
+)
+
+// activeReader tracks how many streams are being read at once.
+type activeReader struct {
+	r            io.Reader
+	active, peak *atomic.Int32
+	started      bool
+}
+
+func (a *activeReader) Read(p []byte) (int, error) {
+	if !a.started {
+		a.started = true
+		n := a.active.Add(1)
+		for {
+			old := a.peak.Load()
+			if n <= old || a.peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+	}
+	n, err := a.r.Read(p)
+	if err == io.EOF {
+		a.active.Add(-1)
+	}
+	return n, err
+}
+
+// TestFanIn ingests identical streams concurrently and checks that each
+// gets a complete chunk list, data is stored once, and the stream limit
+// is respected.
+func TestFanIn(t *testing.T) {
+	data, _ := datagen.Bytes(datagen.Config{Size: 1 << 20, Seed: 5})
+	params := fastcdc.NewParams(2<<10, 8<<10, 32<<10, nil)
+
+	var stored bytes.Buffer
+	cw := NewChunkWriter(&stored, storage.NewMemoryIndex())
+	f := NewFanIn(cw, fastcdc.NewChunker(params), params.MaxSize, sha256.New, 2)
+
+	var active, peak atomic.Int32
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := &activeReader{r: bytes.NewReader(data), active: &active, peak: &peak}
+			chunks, err := f.Ingest(context.Background(), r)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			var total int
+			for _, ch := range chunks {
+				total += ch.Size
+			}
+			if total != len(data) {
+				t.Errorf("stream chunks cover %d bytes, want %d", total, len(data))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if stored.Len() > len(data) {
+		t.Errorf("stored %d bytes for %d bytes of unique data", stored.Len(), len(data))
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("%d streams ingested at once, limit 2", p)
+	}
+}
+
+// TestFanIn_Cancel checks that a canceled context aborts waiting for a slot.
+func TestFanIn_Cancel(t *testing.T) {
+	params := fastcdc.NewParams(2<<10, 8<<10, 32<<10, nil)
+	f := NewFanIn(NewChunkWriter(io.Discard, storage.NewMemoryIndex()), fastcdc.NewChunker(params), params.MaxSize, sha256.New, 1)
+	f.slots <- struct{}{} // occupy the only slot
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := f.Ingest(ctx, bytes.NewReader([]byte("x"))); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
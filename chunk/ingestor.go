@@ -0,0 +1,110 @@
+package chunk
+
+import (
+	"fmt"
+	"hash"
+
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// EmitFunc receives each chunk found by an Ingestor together with its data.
+// data is only valid until EmitFunc returns; copy it to retain it.
+// A non-nil error aborts the current Write or Flush and is returned from it.
+type EmitFunc func(chunk types.Chunk, data []byte) error
+
+// Ingestor is the push-style counterpart of ChunkReader: it implements
+// io.Writer, so sources that push data (HTTP request bodies, archive
+// writers, io.Copy) can be chunked without exposing an io.Reader.
+//
+// Boundaries do not depend on how the input is split across Write calls:
+// a boundary is only searched once bufSize bytes are buffered (or on Flush).
+//
+// An Ingestor is not safe for concurrent use.
+type Ingestor struct {
+	hasher  hash.Hash // chosen hash algorithm
+	chunker Chunker   // boundary finder
+	buf     []byte    // pending bytes not yet emitted
+	bufSize int       // maximum chunk size
+	offset  int64     // stream offset of buf[0]
+	emit    EmitFunc  // chunk callback
+}
+
+// NewIngestor creates a new Ingestor.
+//
+// Parameters:
+//   - hasher: the chosen hash function (e.g. sha256.New())
+//   - bufSize: the maximum chunk size in bytes (typically params.MaxSize)
+//   - chunker: the boundary finder, e.g. fastcdc.NewChunker(params)
+//   - emit: called for every chunk, in stream order
+func NewIngestor(hasher hash.Hash, bufSize int, chunker Chunker, emit EmitFunc) *Ingestor {
+	return &Ingestor{
+		hasher:  hasher,
+		chunker: chunker,
+		buf:     make([]byte, 0, 2*bufSize),
+		bufSize: bufSize,
+		emit:    emit,
+	}
+}
+
+// Write buffers p and emits every chunk whose boundary is now known.
+// It always consumes all of p unless the emit callback fails.
+func (in *Ingestor) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		// Top up the buffer to at most bufSize pending bytes
+		n := min(len(p), in.bufSize-len(in.buf))
+		in.buf = append(in.buf, p[:n]...)
+		p = p[n:]
+		written += n
+
+		if len(in.buf) < in.bufSize {
+			break
+		}
+		if err := in.cut(); err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// Flush emits all remaining buffered data as the final chunk(s).
+// The Ingestor can keep being written to afterwards; offsets continue.
+func (in *Ingestor) Flush() error {
+	for len(in.buf) > 0 {
+		if err := in.cut(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Offset returns the stream offset of the next byte to be emitted.
+func (in *Ingestor) Offset() int64 {
+	return in.offset
+}
+
+// cut emits one chunk from the front of the buffer.
+func (in *Ingestor) cut() error {
+	cut := clampCut(in.chunker.NextBoundary(in.buf), len(in.buf))
+	data := in.buf[:cut]
+
+	in.hasher.Reset()
+	in.hasher.Write(data)
+
+	ch := types.Chunk{
+		Offset: in.offset,
+		Size:   cut,
+		Hash:   in.hasher.Sum(nil),
+		Zero:   isZero(data),
+	}
+	if err := in.emit(ch, data); err != nil {
+		return fmt.Errorf("emit chunk at offset %d: %w", in.offset, err)
+	}
+
+	// Shift leftover bytes to start of buffer
+	in.buf = in.buf[:copy(in.buf, in.buf[cut:])]
+	in.offset += int64(cut)
+
+	return nil
+}
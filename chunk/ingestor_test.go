@@ -0,0 +1,82 @@
+package chunk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/AumSahayata/cdcgo/datagen"
+	"github.com/AumSahayata/cdcgo/fastcdc"
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// ingestAll writes data to a new Ingestor in pieces of the given size and
+// returns the emitted chunks.
+func ingestAll(t *testing.T, data []byte, piece int, params fastcdc.Params) []types.Chunk {
+	t.Helper()
+
+	var chunks []types.Chunk
+	var rebuilt []byte
+	in := NewIngestor(sha256.New(), params.MaxSize, fastcdc.NewChunker(params), func(ch types.Chunk, d []byte) error {
+		chunks = append(chunks, ch)
+		rebuilt = append(rebuilt, d...)
+		return nil
+	})
+
+	for off := 0; off < len(data); off += piece {
+		if _, err := in.Write(data[off:min(off+piece, len(data))]); err != nil {
+			t.Fatalf("unexpected write error: %v", err)
+		}
+	}
+	if err := in.Flush(); err != nil {
+		t.Fatalf("unexpected flush error: %v", err)
+	}
+
+	if !bytes.Equal(rebuilt, data) {
+		t.Fatalf("emitted data does not reassemble the input (piece=%d)", piece)
+	}
+	return chunks
+}
+
+// TestIngestor_WriteSizeIndependent verifies that chunk boundaries do not
+// depend on how the input is split across Write calls.
+func TestIngestor_WriteSizeIndependent(t *testing.T) {
+	data, err := datagen.Bytes(datagen.Config{Size: 300_000, Seed: 5})
+	if err != nil {
+		t.Fatalf("datagen failed: %v", err)
+	}
+	params := fastcdc.NewParams(1<<10, 4<<10, 16<<10, nil)
+
+	want := ingestAll(t, data, len(data), params)
+	for _, piece := range []int{1, 7, 4096, 100_000} {
+		got := ingestAll(t, data, piece, params)
+		if len(got) != len(want) {
+			t.Fatalf("piece=%d: %d chunks, want %d", piece, len(got), len(want))
+		}
+		for i := range got {
+			if got[i].Offset != want[i].Offset || !got[i].Equal(want[i]) {
+				t.Fatalf("piece=%d: chunk %d = %v, want %v", piece, i, got[i], want[i])
+			}
+		}
+	}
+
+	for _, ch := range want {
+		if ch.Size > params.MaxSize {
+			t.Errorf("chunk larger than max size: %v", ch)
+		}
+	}
+}
+
+// TestIngestor_EmitError verifies that callback errors abort the write.
+func TestIngestor_EmitError(t *testing.T) {
+	errStop := errors.New("stop")
+	params := fastcdc.NewParams(50, 100, 200, nil)
+	in := NewIngestor(sha256.New(), 200, fastcdc.NewChunker(params), func(types.Chunk, []byte) error {
+		return errStop
+	})
+
+	if _, err := in.Write(make([]byte, 1000)); !errors.Is(err, errStop) {
+		t.Errorf("expected emit error, got %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package chunk
+
+import "github.com/AumSahayata/cdcgo/types"
+
+// Observer receives events from ChunkReader and ChunkWriter.
+//
+// It lets applications plug in logging, metrics, or auditing without
+// wrapping every component. Callbacks are invoked synchronously on the
+// goroutine that triggered the event, so implementations should be fast
+// and must be safe for concurrent use if the observed components are.
+//
+// Embed NopObserver to implement only the callbacks you need.
+type Observer interface {
+	OnChunkProduced(chunk types.Chunk)      // ChunkReader emitted a chunk
+	OnChunkStored(chunk types.Chunk, n int) // ChunkWriter wrote n bytes of a unique chunk
+	OnDuplicate(chunk types.Chunk)          // ChunkWriter skipped a duplicate chunk
+	OnZeroChunk(chunk types.Chunk)          // ChunkWriter skipped an all-zero chunk (stored as a hole)
+	OnError(op string, err error)           // an operation ("read", "write", "index") failed
+}
+
+// NopObserver implements Observer with no-op callbacks.
+type NopObserver struct{}
+
+func (NopObserver) OnChunkProduced(types.Chunk)    {}
+func (NopObserver) OnChunkStored(types.Chunk, int) {}
+func (NopObserver) OnDuplicate(types.Chunk)        {}
+func (NopObserver) OnZeroChunk(types.Chunk)        {}
+func (NopObserver) OnError(string, error)          {}
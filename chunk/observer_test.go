@@ -0,0 +1,79 @@
+package chunk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"testing"
+
+	"github.com/AumSahayata/cdcgo/fastcdc"
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// countingObserver records how many times each callback fired.
+type countingObserver struct {
+	NopObserver
+	produced, stored, duplicates, zeros, errors int
+}
+
+func (o *countingObserver) OnChunkProduced(types.Chunk)    { o.produced++ }
+func (o *countingObserver) OnChunkStored(types.Chunk, int) { o.stored++ }
+func (o *countingObserver) OnDuplicate(types.Chunk)        { o.duplicates++ }
+func (o *countingObserver) OnZeroChunk(types.Chunk)        { o.zeros++ }
+func (o *countingObserver) OnError(string, error)          { o.errors++ }
+
+// TestObserver_ReaderAndWriter verifies that ChunkReader and ChunkWriter
+// report produced, stored, and duplicate chunks to a shared observer.
+func TestObserver_ReaderAndWriter(t *testing.T) {
+	// Two identical halves so the second half dedupes against the first
+	half := bytes.Repeat([]byte("0123456789abcdef"), 64)
+	data := append(append([]byte{}, half...), half...)
+
+	obs := &countingObserver{}
+	params := fastcdc.NewParams(64, 128, 256, nil)
+	cr := NewChunkReader(bytes.NewReader(data), sha256.New(), 256, fastcdc.NewChunker(params))
+	cr.SetObserver(obs)
+	cw := NewChunkWriter(io.Discard, nil)
+	cw.SetObserver(obs)
+
+	for {
+		ch, err := cr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, _, err := cw.WriteChunk(ch, data[ch.Offset:ch.Offset+int64(ch.Size)]); err != nil {
+			t.Fatalf("unexpected write error: %v", err)
+		}
+	}
+
+	if obs.produced == 0 {
+		t.Fatalf("expected produced chunks to be observed")
+	}
+	if obs.stored+obs.duplicates != obs.produced {
+		t.Errorf("stored (%d) + duplicates (%d) != produced (%d)", obs.stored, obs.duplicates, obs.produced)
+	}
+	if obs.duplicates == 0 {
+		t.Errorf("expected duplicates to be observed")
+	}
+	if obs.errors != 0 {
+		t.Errorf("unexpected errors observed: %d", obs.errors)
+	}
+}
+
+// TestObserver_ReadError verifies that read errors are reported.
+func TestObserver_ReadError(t *testing.T) {
+	obs := &countingObserver{}
+	params := fastcdc.NewParams(50, 100, 200, nil)
+	cr := NewChunkReader(&errorReader{}, sha256.New(), 128, fastcdc.NewChunker(params))
+	cr.SetObserver(obs)
+
+	if _, err := cr.Next(); err == nil {
+		t.Fatalf("expected read error")
+	}
+	if obs.errors != 1 {
+		t.Errorf("errors observed = %d, want 1", obs.errors)
+	}
+}
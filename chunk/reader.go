@@ -4,20 +4,25 @@ import (
 	"hash"
 	"io"
 
-	"github.com/AumSahayata/cdcgo/fastcdc"
 	"github.com/AumSahayata/cdcgo/types"
 )
 
 // ChunkReader implements a streaming API for splitting data into chunks.
-// It reads from an io.Reader, breaks the input into fixed-size chunks,
-// and computes a cryptographic hash for each chunk.
+// It reads from an io.Reader, breaks the input into chunks at the
+// boundaries found by its Chunker, and computes a cryptographic hash for
+// each chunk. Its buffer is filled completely before each cut, so short
+// reads from the source do not change the boundaries.
 type ChunkReader struct {
-	r        io.Reader        // the source
-	hasher   hash.Hash        // chosen hash algorithm
-	buf      []byte           // reusable buffer for reading chunks
-	offset   int64            // where we are in the stream
-	chunker  *fastcdc.Chunker // FastCDC chunker
-	leftover int              // number of bytes from previous read
+	r        io.Reader      // the source
+	hasher   hash.Hash      // chosen hash algorithm
+	buf      []byte         // reusable buffer for reading chunks
+	offset   int64          // where we are in the stream
+	chunker  Chunker        // boundary finder
+	leftover int            // number of bytes from previous read
+	observer Observer       // event hooks
+	zero     bool           // all bytes absorbed so far are zero
+	eof      bool           // the source is exhausted; buf holds the rest
+	visit    func(p []byte) // cr.absorb, bound once to avoid allocations
 }
 
 // NewChunkReader creates a new ChunkReader.
@@ -26,16 +31,29 @@ type ChunkReader struct {
 //   - r: the input source (e.g. file, network, buffer)
 //   - hasher: the chosen hash function (e.g. sha256.New())
 //   - bufSize: the target chunk size in bytes
+//   - chunker: the boundary finder, e.g. fastcdc.NewChunker(params) or FixedSize(n)
 //
 // The ChunkReader will reuse an internal buffer of size bufSize
 // for efficiency, so bufSize also defines the maximum chunk size.
-func NewChunkReader(r io.Reader, hasher hash.Hash, bufSize int, chunker *fastcdc.Chunker) *ChunkReader {
-	return &ChunkReader{
-		r:       r,
-		hasher:  hasher,
-		buf:     make([]byte, bufSize),
-		chunker: chunker,
+func NewChunkReader(r io.Reader, hasher hash.Hash, bufSize int, chunker Chunker) *ChunkReader {
+	cr := &ChunkReader{
+		r:        r,
+		hasher:   hasher,
+		buf:      make([]byte, bufSize),
+		chunker:  chunker,
+		observer: NopObserver{},
 	}
+	cr.visit = cr.absorb
+	return cr
+}
+
+// SetObserver registers an Observer notified of every produced chunk and
+// of read errors. Passing nil restores the default no-op observer.
+func (cr *ChunkReader) SetObserver(o Observer) {
+	if o == nil {
+		o = NopObserver{}
+	}
+	cr.observer = o
 }
 
 // Next reads the next chunk from the underlying stream.
@@ -49,59 +67,69 @@ func NewChunkReader(r io.Reader, hasher hash.Hash, bufSize int, chunker *fastcdc
 // Chunk is safe to use after the call; the underlying buffer may
 // be reused for subsequent chunks.
 func (cr *ChunkReader) Next() (types.Chunk, error) {
-	off := cr.offset
-
-	// Fill buffer if there's space
-	n, err := cr.r.Read(cr.buf[cr.leftover:])
-	total := cr.leftover + n
-
-	// If there is leftover data at EOF, emit it as the final chunk
-	if total > 0 && err == io.EOF {
-		cut := total
-		chunkData := cr.buf[:cut]
-
-		cr.hasher.Reset()
-		cr.hasher.Write(chunkData)
-		hash := cr.hasher.Sum(nil)
+	ch, err := cr.next()
+	if err != nil {
+		if err != io.EOF {
+			cr.observer.OnError("read", err)
+		}
+		return ch, err
+	}
 
-		cr.leftover = 0
-		cr.offset += int64(cut)
+	cr.observer.OnChunkProduced(ch)
+	return ch, nil
+}
 
-		return types.Chunk{
-			Offset: off,
-			Size:   cut,
-			Hash:   hash,
-		}, nil
-	}
+// next implements Next without observer notifications.
+func (cr *ChunkReader) next() (types.Chunk, error) {
+	off := cr.offset
 
-	// If no data read and other error, propagate
-	if total == 0 && err != nil {
-		return types.Chunk{}, err
+	// Fill the buffer completely, however little each Read returns, so
+	// boundaries depend on content only and not on how the source delivers
+	// it (pipes, decompressors, block devices)
+	if !cr.eof {
+		n, err := io.ReadFull(cr.r, cr.buf[cr.leftover:])
+		cr.leftover += n
+		switch {
+		case err == io.EOF || err == io.ErrUnexpectedEOF:
+			cr.eof = true
+		case err != nil:
+			return types.Chunk{}, err
+		}
 	}
 
-	// propagate other errors
-	if n == 0 && err != nil {
-		// no data read, other errors
-		return types.Chunk{}, err
+	if cr.leftover == 0 {
+		return types.Chunk{}, io.EOF
 	}
 
-	// Determine chunk boundary
-	cut := cr.chunker.NextBoundary(cr.buf[:total])
-	chunkData := cr.buf[:cut]
-
-	// Compute hash
-	cr.hasher.Reset()
-	cr.hasher.Write(chunkData)
+	// Determine chunk boundary, hashing the chunk in the same pass. At EOF
+	// the rest of the stream is in buf, so its end is the end of the input.
+	cr.startChunk()
+	cut := nextBoundary(cr.chunker, cr.buf[:cr.leftover], cr.visit)
 	hash := cr.hasher.Sum(nil)
+	zero := cr.zero
 
 	// Shift leftover bytes to start of buffer
-	copy(cr.buf[0:], cr.buf[cut:total])
-	cr.leftover = total - cut
+	copy(cr.buf[0:], cr.buf[cut:cr.leftover])
+	cr.leftover -= cut
 	cr.offset += int64(cut)
 
 	return types.Chunk{
 		Offset: off,
 		Size:   cut,
 		Hash:   hash,
+		Zero:   zero,
 	}, nil
 }
+
+// startChunk resets the per-chunk hash and zero state.
+func (cr *ChunkReader) startChunk() {
+	cr.hasher.Reset()
+	cr.zero = true
+}
+
+// absorb feeds the next piece of the current chunk to the hasher and the
+// zero check while it is still in cache.
+func (cr *ChunkReader) absorb(p []byte) {
+	cr.hasher.Write(p)
+	cr.zero = cr.zero && isZero(p)
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io"
 	"testing"
+	"testing/iotest"
 
+	"github.com/AumSahayata/cdcgo/datagen"
 	"github.com/AumSahayata/cdcgo/fastcdc"
 	"github.com/AumSahayata/cdcgo/types"
 )
@@ -122,6 +124,51 @@ func (e *errorReader) Read(p []byte) (int, error) {
 	return 0, fmt.Errorf("simulated read error")
 }
 
+// TestChunkReader_AlignedShortReads verifies that aligned boundaries hold
+// through ChunkReader when the source returns short reads, as pipes and
+// devices do: every chunk but the last is a multiple of Align, and the
+// chunks match those of an unbuffered read.
+func TestChunkReader_AlignedShortReads(t *testing.T) {
+	data, err := datagen.Bytes(datagen.Config{Size: 2 << 20, Seed: 17})
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := fastcdc.NewParams(4<<10, 16<<10, 64<<10, nil)
+	params.Align = 4096
+
+	chunks := func(r io.Reader) []types.Chunk {
+		cr := NewChunkReader(r, sha256.New(), params.MaxSize, fastcdc.NewChunker(params))
+		var out []types.Chunk
+		for {
+			ch, err := cr.Next()
+			if err == io.EOF {
+				return out
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			out = append(out, ch)
+		}
+	}
+
+	want := chunks(bytes.NewReader(data))
+	got := chunks(iotest.HalfReader(bytes.NewReader(data)))
+
+	for i, ch := range got[:len(got)-1] {
+		if ch.Size%params.Align != 0 {
+			t.Errorf("chunk %d at %d has unaligned size %d", i, ch.Offset, ch.Size)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("short reads gave %d chunks, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Offset != want[i].Offset || !got[i].Equal(want[i]) {
+			t.Errorf("chunk %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
 // TestChunkReader_ReadError ensures that ChunkReader correctly propagates
 // read errors when no bytes are read from the underlying reader.
 func TestChunkReader_ReadError(t *testing.T) {
@@ -164,3 +211,32 @@ func BenchmarkChunkReader(b *testing.B) {
 		})
 	}
 }
+
+// TestChunkReader_StreamingHash checks that chunks larger than one scan
+// block are hashed correctly when hashing happens during the boundary scan.
+func TestChunkReader_StreamingHash(t *testing.T) {
+	data, err := datagen.Bytes(datagen.Config{Size: 8 << 20, Seed: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clear(data[1<<20 : 6<<20]) // zero region for the Zero flag
+
+	params := fastcdc.NewParams(256<<10, 1<<20, 2<<20, nil)
+	zeros := 0
+	for _, ch := range collectChunks(t, data, params) {
+		piece := data[ch.Offset : ch.Offset+int64(ch.Size)]
+		want := sha256.Sum256(piece)
+		if !bytes.Equal(ch.Hash, want[:]) {
+			t.Errorf("chunk at %d: hash mismatch", ch.Offset)
+		}
+		if ch.Zero != isZero(piece) {
+			t.Errorf("chunk at %d: Zero = %v", ch.Offset, ch.Zero)
+		}
+		if ch.Zero {
+			zeros++
+		}
+	}
+	if zeros == 0 {
+		t.Error("expected at least one zero chunk")
+	}
+}
@@ -0,0 +1,134 @@
+package chunk
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// ErrNoWriterAt is returned by Commit when the ChunkWriter's destination
+// does not implement io.WriterAt.
+var ErrNoWriterAt = errors.New("destination does not support WriteAt")
+
+// Reservation is a byte range in the destination claimed by Reserve for one
+// chunk. Pass it to Commit together with the chunk's data.
+type Reservation struct {
+	Chunk  types.Chunk
+	Offset int64 // where the chunk's data starts in the destination
+}
+
+// Reserve claims space for chunk at the end of the destination, so several
+// goroutines can fill one pack concurrently: offsets are assigned in
+// Reserve order under the writer's lock, while the data is written by
+// Commit outside it.
+//
+// A chunk that is already indexed is reported as a duplicate and gets no
+// space. If another reservation for the same chunk is pending, Reserve
+// waits for it to settle: once its Commit succeeds the chunk is a
+// duplicate, and if the Commit fails or the reservation is cancelled the
+// chunk is reserved afresh. Every reservation must therefore be committed
+// or cancelled (see Cancel), and one goroutine must not reserve a chunk
+// twice before settling it. All-zero chunks get an empty reservation and
+// are reported through Observer.OnZeroChunk.
+//
+// Reserve/Commit and WriteChunk share the offset and duplicate tracking but
+// write differently (WriteAt vs Write), so use one style per destination.
+func (cw *ChunkWriter) Reserve(chunk types.Chunk) (res Reservation, duplicate bool, err error) {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	if chunk.Zero {
+		cw.log.Debug("zero chunk skipped", "size", chunk.Size)
+		cw.obs.OnZeroChunk(chunk)
+		return Reservation{Chunk: chunk}, false, nil
+	}
+
+	hashkey := hex.EncodeToString(chunk.Hash)
+
+	if err := cw.checkConfig(chunk); err != nil {
+		cw.log.Error("chunk rejected by repository config", "hash", hashkey, "err", err)
+		cw.obs.OnError("config", err)
+		return Reservation{}, false, err
+	}
+	cw.waitPending(hashkey)
+	if cw.index.Exists(hashkey) {
+		cw.log.Debug("duplicate chunk skipped", "hash", hashkey, "size", chunk.Size)
+		cw.obs.OnDuplicate(chunk)
+		return Reservation{}, true, nil
+	}
+
+	res = Reservation{Chunk: chunk, Offset: cw.offset}
+	cw.pending[hashkey] = res.Offset
+	cw.offset += int64(chunk.Size)
+	return res, false, nil
+}
+
+// Cancel abandons a reservation that will not be committed, for example
+// because the chunk's data could not be produced. Callers waiting on the
+// chunk reserve it afresh; the reserved range is left as unused space.
+// Cancelling a zero, committed, or already cancelled reservation has no
+// effect.
+func (cw *ChunkWriter) Cancel(res Reservation) {
+	if res.Chunk.Zero {
+		return
+	}
+
+	hashkey := hex.EncodeToString(res.Chunk.Hash)
+
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	// Only drop our own reservation, not a later one for the same chunk
+	if off, reserved := cw.pending[hashkey]; !reserved || off != res.Offset {
+		return
+	}
+	delete(cw.pending, hashkey)
+	cw.settled.Broadcast()
+	cw.log.Debug("reservation cancelled", "hash", hashkey, "offset", res.Offset)
+}
+
+// Commit writes data at the reserved offset and indexes the chunk. It may
+// run concurrently with other Commits and Reserves.
+//
+// If it fails, the reservation is dropped: the chunk can be reserved again
+// (at a new offset) and the old range is left as unused space.
+func (cw *ChunkWriter) Commit(res Reservation, data []byte) (written int, err error) {
+	if res.Chunk.Zero {
+		return 0, nil
+	}
+
+	hashkey := hex.EncodeToString(res.Chunk.Hash)
+	if len(data) != res.Chunk.Size {
+		err = fmt.Errorf("commit %s: %d bytes for a %d byte reservation", hashkey, len(data), res.Chunk.Size)
+	} else if wa, ok := cw.w.(io.WriterAt); !ok {
+		err = ErrNoWriterAt
+	} else {
+		written, err = wa.WriteAt(data, res.Offset)
+	}
+
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	if off, reserved := cw.pending[hashkey]; reserved && off == res.Offset {
+		delete(cw.pending, hashkey)
+	}
+	defer cw.settled.Broadcast()
+	if err != nil {
+		cw.log.Error("chunk write failed", "hash", hashkey, "err", err)
+		cw.obs.OnError("write", err)
+		return written, fmt.Errorf("write chunk %s: %w", hashkey, err)
+	}
+
+	if err := cw.index.Add(res.Chunk); err != nil {
+		cw.log.Error("index update failed", "hash", hashkey, "err", err)
+		cw.obs.OnError("index", err)
+		return written, fmt.Errorf("index chunk %s: %w", hashkey, err)
+	}
+	cw.log.Debug("chunk saved", "hash", hashkey, "size", written, "offset", res.Offset)
+	cw.obs.OnChunkStored(res.Chunk, written)
+
+	return written, nil
+}
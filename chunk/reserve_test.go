@@ -0,0 +1,164 @@
+package chunk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// TestReserveCommit fills one pack file from several goroutines and checks
+// that every committed chunk lands at its reserved offset and duplicates
+// get no space.
+func TestReserveCommit(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "pack"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cw := NewChunkWriter(f, nil)
+
+	// 40 distinct payloads, each written by all eight goroutines
+	payloads := make([][]byte, 40)
+	for i := range payloads {
+		payloads[i] = bytes.Repeat([]byte{byte(i + 1)}, 100+i*13)
+	}
+
+	var (
+		mu       sync.Mutex
+		reserved []Reservation
+		wg       sync.WaitGroup
+	)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, data := range payloads {
+				sum := sha256.Sum256(data)
+				res, dup, err := cw.Reserve(types.Chunk{Size: len(data), Hash: sum[:]})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if dup {
+					continue
+				}
+				if _, err := cw.Commit(res, data); err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				reserved = append(reserved, res)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(reserved) != len(payloads) {
+		t.Fatalf("%d chunks committed, want %d", len(reserved), len(payloads))
+	}
+
+	pack, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int
+	for _, res := range reserved {
+		got := pack[res.Offset : res.Offset+int64(res.Chunk.Size)]
+		sum := sha256.Sum256(got)
+		if !bytes.Equal(sum[:], res.Chunk.Hash) {
+			t.Errorf("chunk at %d has wrong content", res.Offset)
+		}
+		total += res.Chunk.Size
+	}
+	if len(pack) != total {
+		t.Errorf("pack size %d, want %d", len(pack), total)
+	}
+}
+
+// TestCommit_Errors checks size mismatches and destinations without WriteAt.
+func TestCommit_Errors(t *testing.T) {
+	var buf bytes.Buffer
+	cw := NewChunkWriter(&buf, nil)
+	data := []byte("payload")
+	sum := sha256.Sum256(data)
+	ch := types.Chunk{Size: len(data), Hash: sum[:]}
+
+	res, _, _ := cw.Reserve(ch)
+	if _, err := cw.Commit(res, data[:3]); err == nil {
+		t.Error("expected error for short data")
+	}
+
+	res, dup, _ := cw.Reserve(ch)
+	if dup {
+		t.Fatal("failed commit should release the reservation")
+	}
+	if _, err := cw.Commit(res, data); !errors.Is(err, ErrNoWriterAt) {
+		t.Errorf("err = %v, want ErrNoWriterAt", err)
+	}
+}
+
+// TestReserve_WaitsForCommit checks that a chunk with a pending reservation
+// is not reported as a duplicate before its Commit: a second Reserve waits,
+// and gets a fresh reservation when the first one is cancelled.
+func TestReserve_WaitsForCommit(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "pack"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cw := NewChunkWriter(f, nil)
+
+	data := []byte("payload")
+	sum := sha256.Sum256(data)
+	ch := types.Chunk{Size: len(data), Hash: sum[:]}
+
+	first, _, err := cw.Reserve(ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		res Reservation
+		dup bool
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		res, dup, err := cw.Reserve(ch)
+		done <- result{res, dup, err}
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Reserve returned while the chunk was still pending")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// The source of the first reservation's data failed
+	cw.Cancel(first)
+
+	r := <-done
+	if r.err != nil || r.dup {
+		t.Fatalf("after cancel: dup=%v err=%v, want a fresh reservation", r.dup, r.err)
+	}
+
+	// A stale Cancel must not drop the new reservation
+	cw.Cancel(first)
+	if _, pending := cw.pending[ch.HexHash()]; !pending {
+		t.Error("stale Cancel dropped a newer reservation")
+	}
+	if _, err := cw.Commit(r.res, data); err != nil {
+		t.Fatal(err)
+	}
+	if _, dup, _ := cw.Reserve(ch); !dup {
+		t.Error("committed chunk should be a duplicate")
+	}
+}
@@ -0,0 +1,137 @@
+package chunk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// ErrNotEmpty is returned by SparseWriter when the destination already
+// holds data and cannot be truncated; holes would expose the old bytes.
+var ErrNotEmpty = errors.New("destination is not empty")
+
+// isZero reports whether every byte of data is 0x00.
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// SparseWriter restores chunks into an io.WriteSeeker, turning all-zero
+// chunks into holes instead of writing their bytes.
+//
+// On filesystems that support sparse files (ext4, XFS, APFS, NTFS, ...)
+// the skipped ranges take no disk space, which matters for raw VM disk
+// images that are mostly empty.
+//
+// Chunks must be written in order, starting at offset 0 of the destination.
+// Because holes are skipped rather than written, existing data would show
+// through them: on the first write the destination is truncated if it
+// implements Truncate(int64) error (as *os.File does), and otherwise it
+// must be empty. Call Close once all chunks are written so that a trailing
+// hole is materialized as file length.
+type SparseWriter struct {
+	w       io.WriteSeeker // restore destination
+	offset  int64          // logical write position
+	hole    bool           // true if the last chunk was skipped
+	started bool           // true once the destination has been prepared
+}
+
+// NewSparseWriter creates a SparseWriter on top of w.
+func NewSparseWriter(w io.WriteSeeker) *SparseWriter {
+	return &SparseWriter{w: w}
+}
+
+// WriteChunk writes a chunk's data at the current position.
+//
+// Zero chunks are skipped by seeking forward chunk.Size bytes; data is
+// ignored for them and may be nil. For all other chunks len(data) bytes
+// are written.
+//
+// The first call truncates the destination, or returns an error wrapping
+// ErrNotEmpty if it holds data and cannot be truncated.
+func (sw *SparseWriter) WriteChunk(chunk types.Chunk, data []byte) error {
+	if !sw.started {
+		if err := sw.prepare(); err != nil {
+			return err
+		}
+		sw.started = true
+	}
+
+	if chunk.Zero {
+		if _, err := sw.w.Seek(int64(chunk.Size), io.SeekCurrent); err != nil {
+			return fmt.Errorf("skip zero chunk at offset %d: %w", sw.offset, err)
+		}
+		sw.offset += int64(chunk.Size)
+		if chunk.Size > 0 {
+			sw.hole = true
+		}
+		return nil
+	}
+
+	n, err := sw.w.Write(data)
+	if err != nil {
+		err = fmt.Errorf("write chunk at offset %d: %w", sw.offset, err)
+	}
+	sw.offset += int64(n)
+	if n > 0 {
+		sw.hole = false
+	}
+	return err
+}
+
+// prepare empties the destination so holes read back as zeros.
+func (sw *SparseWriter) prepare() error {
+	if t, ok := sw.w.(interface{ Truncate(int64) error }); ok {
+		if err := t.Truncate(0); err != nil {
+			return fmt.Errorf("truncate destination: %w", err)
+		}
+		if _, err := sw.w.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("truncate destination: %w", err)
+		}
+		return nil
+	}
+
+	size, err := sw.w.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("check destination size: %w", err)
+	}
+	if size > 0 {
+		return fmt.Errorf("%w: %d bytes", ErrNotEmpty, size)
+	}
+	return nil
+}
+
+// Close extends the destination to its full logical size when the last
+// chunk was a hole.
+//
+// If the destination implements Truncate(int64) error (as *os.File does)
+// it is used; otherwise a single zero byte is written at the final offset.
+// Close does not close the underlying writer.
+func (sw *SparseWriter) Close() error {
+	if !sw.hole {
+		return nil
+	}
+
+	if t, ok := sw.w.(interface{ Truncate(int64) error }); ok {
+		if err := t.Truncate(sw.offset); err != nil {
+			return fmt.Errorf("extend to %d bytes: %w", sw.offset, err)
+		}
+		sw.hole = false
+		return nil
+	}
+
+	if _, err := sw.w.Seek(sw.offset-1, io.SeekStart); err != nil {
+		return fmt.Errorf("extend to %d bytes: %w", sw.offset, err)
+	}
+	if _, err := sw.w.Write([]byte{0}); err != nil {
+		return fmt.Errorf("extend to %d bytes: %w", sw.offset, err)
+	}
+	sw.hole = false
+	return nil
+}
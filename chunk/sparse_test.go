@@ -0,0 +1,180 @@
+package chunk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AumSahayata/cdcgo/fastcdc"
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// TestChunkReader_ZeroDetection verifies that chunks made only of zero bytes
+// are flagged, and that chunks containing data are not.
+func TestChunkReader_ZeroDetection(t *testing.T) {
+	data := append(make([]byte, 400), bytes.Repeat([]byte{0xAB}, 400)...)
+	params := fastcdc.NewParams(50, 100, 200, nil)
+	cr := NewChunkReader(bytes.NewReader(data), sha256.New(), 256, fastcdc.NewChunker(params))
+
+	for {
+		ch, err := cr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := isZero(data[ch.Offset : ch.Offset+int64(ch.Size)])
+		if ch.Zero != want {
+			t.Errorf("chunk at offset %d: Zero = %v, want %v", ch.Offset, ch.Zero, want)
+		}
+	}
+}
+
+// TestChunkWriter_SkipsZeroChunks ensures zero chunks are not stored but
+// are reported to the observer.
+func TestChunkWriter_SkipsZeroChunks(t *testing.T) {
+	buf := &bytes.Buffer{}
+	cw := NewChunkWriter(buf, nil)
+	obs := &countingObserver{}
+	cw.SetObserver(obs)
+
+	data := make([]byte, 64)
+	hash := sha256.Sum256(data)
+	ch := types.Chunk{Size: len(data), Hash: hash[:], Zero: true}
+
+	n, dup, err := cw.WriteChunk(ch, data)
+	if err != nil || dup || n != 0 {
+		t.Fatalf("zero chunk should be skipped: n=%d dup=%v err=%v", n, dup, err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("zero chunk data was written: %d bytes", buf.Len())
+	}
+	if obs.zeros != 1 {
+		t.Errorf("zero chunks observed = %d, want 1", obs.zeros)
+	}
+}
+
+// sparseInput returns test data with zero regions, including a trailing one.
+func sparseInput() []byte {
+	var data []byte
+	data = append(data, bytes.Repeat([]byte{0x11}, 300)...)
+	data = append(data, make([]byte, 1000)...)
+	data = append(data, bytes.Repeat([]byte{0x22}, 300)...)
+	data = append(data, make([]byte, 1000)...)
+	return data
+}
+
+// restoreSparse chunks data and writes every chunk through a SparseWriter
+// on w. It returns the number of zero chunks and the first write error.
+func restoreSparse(t *testing.T, data []byte, w io.WriteSeeker) (zeros int, err error) {
+	t.Helper()
+
+	params := fastcdc.NewParams(50, 100, 200, nil)
+	cr := NewChunkReader(bytes.NewReader(data), sha256.New(), 256, fastcdc.NewChunker(params))
+	sw := NewSparseWriter(w)
+
+	for {
+		ch, err := cr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var chunkData []byte
+		if ch.Zero {
+			zeros++
+		} else {
+			chunkData = data[ch.Offset : ch.Offset+int64(ch.Size)]
+		}
+		if err := sw.WriteChunk(ch, chunkData); err != nil {
+			return zeros, err
+		}
+	}
+	return zeros, sw.Close()
+}
+
+// TestSparseWriter_Restore chunks an input with zero regions, restores it
+// through a SparseWriter into a file, and checks the content round-trips,
+// including a trailing hole. The file starts out holding older, longer
+// data, which must not show through the holes.
+func TestSparseWriter_Restore(t *testing.T) {
+	data := sparseInput()
+
+	path := filepath.Join(t.TempDir(), "restored.img")
+	if err := os.WriteFile(path, bytes.Repeat([]byte{0xFF}, 4000), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	zeros, err := restoreSparse(t, data, f)
+	if err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if zeros == 0 {
+		t.Fatalf("expected at least one zero chunk")
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read restored file: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("restored content mismatch: got %d bytes, want %d", len(got), len(data))
+	}
+}
+
+// seekBuffer is an in-memory io.WriteSeeker without Truncate.
+type seekBuffer struct {
+	buf []byte
+	pos int64
+}
+
+func (b *seekBuffer) Write(p []byte) (int, error) {
+	if end := b.pos + int64(len(p)); end > int64(len(b.buf)) {
+		b.buf = append(b.buf, make([]byte, end-int64(len(b.buf)))...)
+	}
+	n := copy(b.buf[b.pos:], p)
+	b.pos += int64(n)
+	return n, nil
+}
+
+func (b *seekBuffer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += b.pos
+	case io.SeekEnd:
+		offset += int64(len(b.buf))
+	}
+	b.pos = offset
+	return offset, nil
+}
+
+// TestSparseWriter_NotEmpty verifies that a destination which cannot be
+// truncated must be empty, and that an empty one restores correctly.
+func TestSparseWriter_NotEmpty(t *testing.T) {
+	data := sparseInput()
+
+	if _, err := restoreSparse(t, data, &seekBuffer{buf: []byte("stale")}); !errors.Is(err, ErrNotEmpty) {
+		t.Errorf("expected ErrNotEmpty, got %v", err)
+	}
+
+	empty := &seekBuffer{}
+	if _, err := restoreSparse(t, data, empty); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if !bytes.Equal(empty.buf, data) {
+		t.Errorf("restored content mismatch: got %d bytes, want %d", len(empty.buf), len(data))
+	}
+}
@@ -0,0 +1,135 @@
+package chunk
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+	"io"
+	"slices"
+
+	"github.com/AumSahayata/cdcgo/storage"
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// Verify checks that data matches the size and hash recorded in chunk.
+//
+// The hasher must be the same algorithm that produced chunk.Hash; it is
+// reset before use. A mismatch is reported as an error wrapping
+// storage.ErrCorruptChunk.
+func Verify(chunk types.Chunk, data []byte, hasher hash.Hash) error {
+	if len(data) != chunk.Size {
+		return fmt.Errorf("%w: %s: size %d, want %d", storage.ErrCorruptChunk, chunk.HexHash(), len(data), chunk.Size)
+	}
+
+	hasher.Reset()
+	hasher.Write(data)
+	if sum := hasher.Sum(nil); !bytes.Equal(sum, chunk.Hash) {
+		return fmt.Errorf("%w: %s: got hash %x", storage.ErrCorruptChunk, chunk.HexHash(), sum)
+	}
+
+	return nil
+}
+
+// Mismatch describes a divergence between recorded chunks and re-chunked
+// live data.
+//
+// Fields:
+//   - Offset: byte offset where the divergence starts
+//   - Want:   the recorded chunk (zero value if the live data has extra chunks)
+//   - Got:    the live chunk (zero value if a recorded chunk is missing)
+type Mismatch struct {
+	Offset int64
+	Want   types.Chunk
+	Got    types.Chunk
+}
+
+// VerifyChunks re-chunks the stream behind cr and compares it with the
+// recorded list want (for example, the chunk list of a backup).
+//
+// Chunks are matched by hash and size in order, not by position, so after
+// an inserted or deleted range the comparison resynchronizes on the next
+// unchanged chunk even though its offset has shifted. Live chunks between
+// two matches are paired with the recorded chunks they replace; surplus
+// chunks on either side are reported with a zero Want or Got.
+//
+// cr must be configured with the same chunking parameters and hash
+// algorithm that produced want. If all is false, VerifyChunks stops at the
+// first divergence; otherwise it reports every divergent chunk. A nil
+// result means the live data matches.
+func VerifyChunks(cr *ChunkReader, want []types.Chunk, all bool) ([]Mismatch, error) {
+	// Recorded positions of each hash, ascending, for resynchronizing
+	positions := make(map[string][]int, len(want))
+	for i, w := range want {
+		key := w.HexHash()
+		positions[key] = append(positions[key], i)
+	}
+
+	var (
+		mismatches []Mismatch
+		unmatched  []types.Chunk // live chunks since the last match
+		i          int           // next recorded chunk to match
+	)
+
+	// skipTo reports unmatched and want[i:j] as mismatches, pairing them in
+	// order, and continues matching at j. It returns true if VerifyChunks
+	// should stop.
+	skipTo := func(j int) bool {
+		for k := range max(len(unmatched), j-i) {
+			var m Mismatch
+			if k < j-i {
+				m.Want = want[i+k]
+				m.Offset = m.Want.Offset
+			}
+			if k < len(unmatched) {
+				m.Got = unmatched[k]
+				m.Offset = m.Got.Offset
+			}
+			mismatches = append(mismatches, m)
+			if !all {
+				return true
+			}
+		}
+		unmatched = unmatched[:0]
+		i = j
+		return false
+	}
+
+	for {
+		got, err := cr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return mismatches, err
+		}
+
+		j := nextPosition(positions[got.HexHash()], i)
+		if j < 0 || !got.Equal(want[j]) {
+			unmatched = append(unmatched, got)
+			if !all {
+				skipTo(min(i+1, len(want)))
+				return mismatches, nil
+			}
+			continue
+		}
+
+		if skipTo(j) {
+			return mismatches, nil
+		}
+		i = j + 1
+	}
+
+	// Live data ended: whatever is left on either side diverges
+	skipTo(len(want))
+	return mismatches, nil
+}
+
+// nextPosition returns the first position in the ascending list that is at
+// least from, or -1 if there is none.
+func nextPosition(list []int, from int) int {
+	k, _ := slices.BinarySearch(list, from)
+	if k == len(list) {
+		return -1
+	}
+	return list[k]
+}
@@ -0,0 +1,138 @@
+package chunk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/AumSahayata/cdcgo/datagen"
+	"github.com/AumSahayata/cdcgo/fastcdc"
+	"github.com/AumSahayata/cdcgo/storage"
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// TestVerify checks that matching data passes and that size or content
+// mismatches are reported as storage.ErrCorruptChunk.
+func TestVerify(t *testing.T) {
+	data := []byte("verified chunk")
+	hash := sha256.Sum256(data)
+	ch := types.Chunk{Size: len(data), Hash: hash[:]}
+
+	if err := Verify(ch, data, sha256.New()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := Verify(ch, data[:4], sha256.New()); !errors.Is(err, storage.ErrCorruptChunk) {
+		t.Errorf("short data: expected ErrCorruptChunk, got %v", err)
+	}
+
+	tampered := []byte("verified chunK")
+	if err := Verify(ch, tampered, sha256.New()); !errors.Is(err, storage.ErrCorruptChunk) {
+		t.Errorf("tampered data: expected ErrCorruptChunk, got %v", err)
+	}
+}
+
+// collectChunks chunks data with the given params and returns the chunk list.
+func collectChunks(t *testing.T, data []byte, params fastcdc.Params) []types.Chunk {
+	t.Helper()
+
+	cr := NewChunkReader(bytes.NewReader(data), sha256.New(), params.MaxSize, fastcdc.NewChunker(params))
+	var chunks []types.Chunk
+	for {
+		ch, err := cr.Next()
+		if err == io.EOF {
+			return chunks
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		chunks = append(chunks, ch)
+	}
+}
+
+// TestVerifyChunks verifies that unchanged data matches and that a
+// modification or truncation is reported at the right offset.
+func TestVerifyChunks(t *testing.T) {
+	data := make([]byte, 8<<10)
+	for i := range data {
+		data[i] = byte(i * 7 % 251)
+	}
+	params := fastcdc.NewParams(256, 1<<10, 4<<10, nil)
+	recorded := collectChunks(t, data, params)
+
+	newReader := func(b []byte) *ChunkReader {
+		return NewChunkReader(bytes.NewReader(b), sha256.New(), params.MaxSize, fastcdc.NewChunker(params))
+	}
+
+	// Unchanged
+	mm, err := VerifyChunks(newReader(data), recorded, true)
+	if err != nil || mm != nil {
+		t.Fatalf("expected match, got %v, %v", mm, err)
+	}
+
+	// Modified byte inside the last chunk
+	modified := append([]byte(nil), data...)
+	last := recorded[len(recorded)-1]
+	modified[last.Offset] ^= 0xFF
+	mm, err = VerifyChunks(newReader(modified), recorded, false)
+	if err != nil || len(mm) != 1 {
+		t.Fatalf("expected one mismatch, got %v, %v", mm, err)
+	}
+	if mm[0].Offset != last.Offset {
+		t.Errorf("mismatch offset = %d, want %d", mm[0].Offset, last.Offset)
+	}
+
+	// Truncated: every missing chunk is reported in "all" mode
+	truncated := data[:recorded[1].Offset]
+	mm, err = VerifyChunks(newReader(truncated), recorded, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mm) != len(recorded)-1 {
+		t.Errorf("mismatches = %d, want %d", len(mm), len(recorded)-1)
+	}
+}
+
+// TestVerifyChunks_Insert verifies that a single inserted byte is reported
+// only around the insertion: the chunks after it match again by hash even
+// though their offsets have shifted.
+func TestVerifyChunks_Insert(t *testing.T) {
+	data, err := datagen.Bytes(datagen.Config{Size: 64 << 10, Seed: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := fastcdc.NewParams(256, 1<<10, 4<<10, nil)
+	recorded := collectChunks(t, data, params)
+
+	k := len(recorded) / 2
+	at := recorded[k].Offset + int64(recorded[k].Size)/2
+	inserted := slices.Concat(data[:at], []byte{0x42}, data[at:])
+
+	newReader := func(b []byte) *ChunkReader {
+		return NewChunkReader(bytes.NewReader(b), sha256.New(), params.MaxSize, fastcdc.NewChunker(params))
+	}
+
+	mm, err := VerifyChunks(newReader(inserted), recorded, false)
+	if err != nil || len(mm) != 1 {
+		t.Fatalf("expected one mismatch, got %v, %v", mm, err)
+	}
+	if mm[0].Offset != recorded[k].Offset {
+		t.Errorf("first mismatch at %d, want %d", mm[0].Offset, recorded[k].Offset)
+	}
+
+	mm, err = VerifyChunks(newReader(inserted), recorded, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mm) == 0 || len(mm) > 3 {
+		t.Fatalf("mismatches = %d, want 1-3 around the insertion", len(mm))
+	}
+	for _, m := range mm {
+		if m.Offset < recorded[k].Offset || m.Offset > at+int64(2*params.MaxSize) {
+			t.Errorf("mismatch at %d, far from insertion at %d", m.Offset, at)
+		}
+	}
+}
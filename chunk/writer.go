@@ -2,7 +2,9 @@ package chunk
 
 import (
 	"encoding/hex"
+	"fmt"
 	"io"
+	"log/slog"
 	"sync"
 
 	"github.com/AumSahayata/cdcgo/storage"
@@ -12,27 +14,90 @@ import (
 // ChunkWriter writes chunks to an underlying storage
 // and avoids duplicates using an Index.
 type ChunkWriter struct {
-	w      io.Writer     // underlying storage
-	index  storage.Index // dedupe index
-	offset int64         // write position
-	mu     sync.Mutex
+	w       io.Writer        // underlying storage
+	index   storage.Index    // dedupe index
+	offset  int64            // write position
+	pending map[string]int64 // hashes reserved but not yet committed → reserved offset
+	settled *sync.Cond       // broadcast when a pending reservation is committed or dropped
+	obs     Observer         // event hooks
+	log     *slog.Logger     // structured logger, silent by default
+	mu      sync.Mutex
 }
 
 // NewChunkWriter creates a new ChunkWriter.
 // If no index is provided, a MemoryIndex will be used.
+//
+// If idx carries a repository config (storage.ConfigIndex, e.g. a
+// PersistentIndexJSON opened next to a cdcgo-config.json), chunks that
+// violate it are refused with an error wrapping storage.ErrConfigMismatch.
 func NewChunkWriter(w io.Writer, idx storage.Index) *ChunkWriter {
 	if idx == nil {
 		idx = storage.NewMemoryIndex()
 	}
 
-	return &ChunkWriter{
-		w:     w,
-		index: idx,
+	cw := &ChunkWriter{
+		w:       w,
+		index:   idx,
+		pending: make(map[string]int64),
+		obs:     NopObserver{},
+		log:     slog.New(slog.DiscardHandler),
+	}
+	cw.settled = sync.NewCond(&cw.mu)
+	return cw
+}
+
+// NewChunkWriterWithConfig creates a ChunkWriter for a repository whose
+// settings must match want. The config is created on idx if it has none,
+// otherwise want is checked against it, so a writer with other chunking
+// parameters or hash algorithm fails here instead of storing chunks that
+// never dedupe. idx must implement storage.ConfigIndex; nil means a new
+// MemoryIndex.
+func NewChunkWriterWithConfig(w io.Writer, idx storage.Index, want storage.Config) (*ChunkWriter, error) {
+	if idx == nil {
+		idx = storage.NewMemoryIndex()
+	}
+
+	ci, ok := idx.(storage.ConfigIndex)
+	if !ok {
+		return nil, fmt.Errorf("index %T cannot store a repository config", idx)
+	}
+	if _, err := ci.EnsureConfig(want); err != nil {
+		return nil, err
+	}
+	return NewChunkWriter(w, idx), nil
+}
+
+// SetLogger sets the logger used for debug events (chunk saved, duplicate
+// skipped) and write or index errors. Passing nil silences logging again.
+func (cw *ChunkWriter) SetLogger(l *slog.Logger) {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	if l == nil {
+		l = slog.New(slog.DiscardHandler)
+	}
+	cw.log = l
+}
+
+// SetObserver registers an Observer notified of stored and duplicate chunks
+// and of write or index errors. Callbacks run while the writer's lock is
+// held, so they must not call back into the ChunkWriter.
+// Passing nil restores the default no-op observer.
+func (cw *ChunkWriter) SetObserver(o Observer) {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	if o == nil {
+		o = NopObserver{}
 	}
+	cw.obs = o
 }
 
 // WriteChunk writes a chunk’s data to the underlying writer if it is unique.
-// Duplicate chunks are skipped.
+// Duplicate chunks are skipped; a chunk with a pending Reserve is only
+// treated as a duplicate once its Commit succeeds. All-zero chunks
+// (chunk.Zero) carry no stored data and are neither written nor indexed;
+// they are reported to the Observer through OnZeroChunk.
 //
 // Returns:
 //   - n: number of bytes written
@@ -42,29 +107,75 @@ func (cw *ChunkWriter) WriteChunk(chunk types.Chunk, data []byte) (written int,
 	cw.mu.Lock()
 	defer cw.mu.Unlock()
 
+	if chunk.Zero {
+		cw.log.Debug("zero chunk skipped", "size", chunk.Size)
+		cw.obs.OnZeroChunk(chunk)
+		return 0, false, nil
+	}
+
 	hashkey := hex.EncodeToString(chunk.Hash)
 
+	if err := cw.checkConfig(chunk); err != nil {
+		cw.log.Error("chunk rejected by repository config", "hash", hashkey, "err", err)
+		cw.obs.OnError("config", err)
+		return 0, false, err
+	}
+
+	cw.waitPending(hashkey)
 	if cw.index.Exists(hashkey) {
 		// Chunk already written; skip writing
+		cw.log.Debug("duplicate chunk skipped", "hash", hashkey, "size", chunk.Size)
+		cw.obs.OnDuplicate(chunk)
 		return 0, true, nil
 	}
 
 	// Write chunk data
 	n, err := cw.w.Write(data)
 	if err != nil {
-		return n, false, err
+		cw.log.Error("chunk write failed", "hash", hashkey, "err", err)
+		cw.obs.OnError("write", err)
+		return n, false, fmt.Errorf("write chunk %s: %w", hashkey, err)
 	}
 
 	// Update index and offset
 	err = cw.index.Add(chunk)
 	if err != nil {
-		return n, false, err
+		cw.log.Error("index update failed", "hash", hashkey, "err", err)
+		cw.obs.OnError("index", err)
+		return n, false, fmt.Errorf("index chunk %s: %w", hashkey, err)
 	}
 	cw.offset += int64(n)
+	cw.log.Debug("chunk saved", "hash", hashkey, "size", n, "offset", cw.offset-int64(n))
+	cw.obs.OnChunkStored(chunk, n)
 
 	return n, false, nil
 }
 
+// waitPending blocks until no reservation for hashkey is pending, so a
+// chunk is only reported as a duplicate once its Commit has succeeded.
+// cw.mu must be held; it is released while waiting.
+func (cw *ChunkWriter) waitPending(hashkey string) {
+	for {
+		if _, reserved := cw.pending[hashkey]; !reserved {
+			return
+		}
+		cw.settled.Wait()
+	}
+}
+
+// checkConfig refuses chunks that violate the index's repository config.
+func (cw *ChunkWriter) checkConfig(chunk types.Chunk) error {
+	ci, ok := cw.index.(storage.ConfigIndex)
+	if !ok {
+		return nil
+	}
+	cfg, ok := ci.Config()
+	if !ok {
+		return nil
+	}
+	return cfg.CheckChunk(chunk)
+}
+
 // Flush flushes the underlying writer if supported.
 func (cw *ChunkWriter) Flush() error {
 	if f, ok := cw.w.(interface{ Flush() error }); ok {
@@ -2,12 +2,17 @@ package chunk
 
 import (
 	"bytes"
+	"crypto/sha1"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"strings"
 	"testing"
 
 	"github.com/AumSahayata/cdcgo/fastcdc"
+	"github.com/AumSahayata/cdcgo/storage"
 	"github.com/AumSahayata/cdcgo/types"
 )
 
@@ -63,6 +68,69 @@ func TestChunkWriter_Basic(t *testing.T) {
 	}
 }
 
+// TestChunkWriter_Logger verifies that saved and duplicate chunks are
+// reported as structured debug events.
+func TestChunkWriter_Logger(t *testing.T) {
+	var logs bytes.Buffer
+	cw := NewChunkWriter(io.Discard, nil)
+	cw.SetLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	data := []byte("logged chunk")
+	hash := sha256.Sum256(data)
+	ch := types.Chunk{Size: len(data), Hash: hash[:]}
+
+	_, _, _ = cw.WriteChunk(ch, data)
+	_, _, _ = cw.WriteChunk(ch, data)
+
+	out := logs.String()
+	if !strings.Contains(out, `msg="chunk saved"`) || !strings.Contains(out, "hash="+ch.HexHash()) {
+		t.Errorf("missing chunk saved event in logs:\n%s", out)
+	}
+	if !strings.Contains(out, `msg="duplicate chunk skipped"`) {
+		t.Errorf("missing duplicate event in logs:\n%s", out)
+	}
+}
+
+// TestChunkWriter_Config verifies that a writer on an index with a
+// repository config refuses chunks that config could not have produced,
+// and that NewChunkWriterWithConfig rejects a mismatched config.
+func TestChunkWriter_Config(t *testing.T) {
+	idx := storage.NewMemoryIndex()
+	cfg := storage.NewConfig(fastcdc.NewParams(64, 256, 1024, nil), "sha256")
+
+	cw, err := NewChunkWriterWithConfig(io.Discard, idx, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data := []byte("configured chunk")
+	sum := sha256.Sum256(data)
+	if _, _, err := cw.WriteChunk(types.Chunk{Size: len(data), Hash: sum[:]}, data); err != nil {
+		t.Errorf("valid chunk rejected: %v", err)
+	}
+
+	big := bytes.Repeat([]byte{1}, 2048)
+	sum = sha256.Sum256(big)
+	if _, _, err := cw.WriteChunk(types.Chunk{Size: len(big), Hash: sum[:]}, big); !errors.Is(err, storage.ErrConfigMismatch) {
+		t.Errorf("oversized chunk: expected ErrConfigMismatch, got %v", err)
+	}
+
+	short := sha1.Sum(data)
+	if _, _, err := cw.WriteChunk(types.Chunk{Size: len(data), Hash: short[:]}, data); !errors.Is(err, storage.ErrConfigMismatch) {
+		t.Errorf("wrong hash length: expected ErrConfigMismatch, got %v", err)
+	}
+
+	// A plain NewChunkWriter on the same index enforces the stored config too
+	if _, _, err := NewChunkWriter(io.Discard, idx).WriteChunk(types.Chunk{Size: len(big), Hash: sum[:]}, big); !errors.Is(err, storage.ErrConfigMismatch) {
+		t.Errorf("NewChunkWriter: expected ErrConfigMismatch, got %v", err)
+	}
+
+	other := storage.NewConfig(fastcdc.NewParams(128, 512, 2048, nil), "sha256")
+	if _, err := NewChunkWriterWithConfig(io.Discard, idx, other); !errors.Is(err, storage.ErrConfigMismatch) {
+		t.Errorf("expected ErrConfigMismatch, got %v", err)
+	}
+}
+
 // BenchmarkChunkWriter measures throughput and allocations of ChunkWriter.
 //
 // It repeatedly writes 16MB of sample data split into FastCDC chunks
@@ -0,0 +1,136 @@
+// Package datagen produces deterministic pseudo-random data streams with a
+// controllable amount of redundancy, for reproducible dedup and throughput
+// experiments.
+package datagen
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+)
+
+// Config describes the stream to generate.
+//
+// Fields:
+//   - Size:         total stream length in bytes
+//   - BlockSize:    granularity of redundancy in bytes (default 4 KiB)
+//   - DupRatio:     probability in [0,1] that a block repeats an earlier one
+//   - MutationRate: fraction in [0,1] of bytes altered in each repeated block
+//   - PoolSize:     number of distinct earlier blocks eligible for repetition (default 64)
+//   - Seed:         random seed; the same Config always yields the same stream
+type Config struct {
+	Size         int64
+	BlockSize    int
+	DupRatio     float64
+	MutationRate float64
+	PoolSize     int
+	Seed         uint64
+}
+
+// Generator is an io.Reader over a generated stream.
+type Generator struct {
+	cfg   Config
+	rng   *rand.Rand
+	pool  [][]byte // earlier unique blocks eligible for repetition
+	next  int      // pool slot to replace once the pool is full
+	block []byte   // current block
+	pos   int      // read position within block
+	left  int64    // bytes still to emit
+}
+
+// New creates a Generator for cfg.
+// Returns an error if a ratio is outside [0,1] or a size is negative.
+func New(cfg Config) (*Generator, error) {
+	if cfg.BlockSize == 0 {
+		cfg.BlockSize = 4 << 10
+	}
+	if cfg.PoolSize == 0 {
+		cfg.PoolSize = 64
+	}
+
+	switch {
+	case cfg.Size < 0 || cfg.BlockSize < 0 || cfg.PoolSize < 0:
+		return nil, fmt.Errorf("datagen: negative size in config %+v", cfg)
+	case cfg.DupRatio < 0 || cfg.DupRatio > 1:
+		return nil, fmt.Errorf("datagen: DupRatio %v out of range [0,1]", cfg.DupRatio)
+	case cfg.MutationRate < 0 || cfg.MutationRate > 1:
+		return nil, fmt.Errorf("datagen: MutationRate %v out of range [0,1]", cfg.MutationRate)
+	}
+
+	return &Generator{
+		cfg:  cfg,
+		rng:  rand.New(rand.NewPCG(cfg.Seed, cfg.Seed^0x6a09e667f3bcc909)),
+		left: cfg.Size,
+	}, nil
+}
+
+// Bytes generates the whole stream described by cfg in memory.
+func Bytes(cfg Config) ([]byte, error) {
+	g, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(g)
+}
+
+// Read implements io.Reader.
+func (g *Generator) Read(p []byte) (int, error) {
+	if g.left <= 0 {
+		return 0, io.EOF
+	}
+
+	n := 0
+	for n < len(p) && g.left > 0 {
+		if g.pos == len(g.block) {
+			g.fill()
+		}
+
+		c := copy(p[n:], g.block[g.pos:])
+		c = int(min(int64(c), g.left))
+		g.pos += c
+		g.left -= int64(c)
+		n += c
+	}
+
+	return n, nil
+}
+
+// fill produces the next block, either fresh random bytes or a (possibly
+// mutated) copy of an earlier block.
+func (g *Generator) fill() {
+	if g.block == nil {
+		g.block = make([]byte, g.cfg.BlockSize)
+	}
+	g.pos = 0
+
+	if len(g.pool) > 0 && g.rng.Float64() < g.cfg.DupRatio {
+		copy(g.block, g.pool[g.rng.IntN(len(g.pool))])
+		g.mutate()
+		return
+	}
+
+	for i := range g.block {
+		g.block[i] = byte(g.rng.Uint32())
+	}
+	g.remember()
+}
+
+// mutate alters roughly MutationRate of the current block's bytes.
+func (g *Generator) mutate() {
+	n := int(float64(len(g.block)) * g.cfg.MutationRate)
+	for range n {
+		g.block[g.rng.IntN(len(g.block))] = byte(g.rng.Uint32())
+	}
+}
+
+// remember adds the current block to the repetition pool, replacing the
+// oldest entry once the pool is full.
+func (g *Generator) remember() {
+	saved := append([]byte(nil), g.block...)
+	if len(g.pool) < g.cfg.PoolSize {
+		g.pool = append(g.pool, saved)
+		return
+	}
+	g.pool[g.next] = saved
+	g.next = (g.next + 1) % g.cfg.PoolSize
+}
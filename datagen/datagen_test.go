@@ -0,0 +1,74 @@
+package datagen
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+// TestBytes_Deterministic verifies that the same config yields the same
+// stream and that the stream has exactly the requested size.
+func TestBytes_Deterministic(t *testing.T) {
+	cfg := Config{Size: 100_001, BlockSize: 1024, DupRatio: 0.3, MutationRate: 0.01, Seed: 9}
+
+	a, err := Bytes(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := Bytes(cfg)
+
+	if len(a) != int(cfg.Size) {
+		t.Errorf("stream length = %d, want %d", len(a), cfg.Size)
+	}
+	if !bytes.Equal(a, b) {
+		t.Errorf("streams differ for the same config")
+	}
+
+	cfg.Seed = 10
+	c, _ := Bytes(cfg)
+	if bytes.Equal(a, c) {
+		t.Errorf("streams identical for different seeds")
+	}
+}
+
+// TestBytes_DupRatio verifies that the share of repeated blocks roughly
+// matches DupRatio when no mutations are applied.
+func TestBytes_DupRatio(t *testing.T) {
+	for _, ratio := range []float64{0, 0.3, 0.7} {
+		cfg := Config{Size: 4 << 20, BlockSize: 4 << 10, DupRatio: ratio, Seed: 1}
+		data, err := Bytes(cfg)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		seen := make(map[[sha256.Size]byte]bool)
+		blocks, dups := 0, 0
+		for off := 0; off < len(data); off += cfg.BlockSize {
+			sum := sha256.Sum256(data[off : off+cfg.BlockSize])
+			if seen[sum] {
+				dups++
+			}
+			seen[sum] = true
+			blocks++
+		}
+
+		got := float64(dups) / float64(blocks)
+		if got < ratio-0.05 || got > ratio+0.05 {
+			t.Errorf("DupRatio %.2f: observed %.3f", ratio, got)
+		}
+	}
+}
+
+// TestNew_InvalidConfig verifies config validation.
+func TestNew_InvalidConfig(t *testing.T) {
+	bad := []Config{
+		{Size: -1},
+		{Size: 10, DupRatio: 1.5},
+		{Size: 10, MutationRate: -0.1},
+	}
+	for _, cfg := range bad {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("expected error for config %+v", cfg)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package fastcdc
 
+import "bytes"
+
 // gearTable is a random table of 256 values for rolling hash.
 var gearTable = [256]uint64{
 	0xa8e274c800dc3426, 0x2283b1dba800c778, 0x7d18b312bdae38d4, 0xf23575802697a80f,
@@ -77,12 +79,85 @@ type Chunker struct {
 // bufSize is the internal buffer size in bytes. Must be >= MaxSize of the chunker.
 
 func NewChunker(params Params) *Chunker {
+	// Derive the masks if only the level was set
+	if params.NormLevel > 0 && params.MaskS == 0 && params.MaskL == 0 {
+		params = params.Normalized(params.NormLevel)
+	}
 	return &Chunker{params: params}
 }
 
+// scanBlock is how many bytes NextBoundaryFunc scans between visits,
+// small enough that visited bytes are still in cache.
+const scanBlock = 32 << 10
+
 // NextBoundary finds the next chunk boundary given a buffer of data.
 // Returns the next chunk boundary as an offset in bytes relative to the buffer start.
+//
+// If Params.Align is set, the boundary is rounded up to a multiple of Align
+// (and never beyond MaxSize rounded down to Align). Only a cut at the very
+// end of buf, i.e. the tail of the input, may be unaligned, or every cut if
+// MaxSize < Align.
 func (c *Chunker) NextBoundary(buf []byte) int {
+	return c.NextBoundaryFunc(buf, nil)
+}
+
+// NextBoundaryFunc is like NextBoundary, but also calls visit with
+// consecutive pieces of buf[:cut] as they are scanned. Callers can feed the
+// pieces to a cryptographic hash so the chunk is hashed in the same pass as
+// the boundary search, instead of a second pass over a possibly large chunk.
+// visit may be nil.
+func (c *Chunker) NextBoundaryFunc(buf []byte, visit func(p []byte)) int {
+	// Rounding to Align may move the cut back below the scanned bytes when
+	// MaxSize is not a multiple of Align, so only visit once the cut is final
+	aligned := c.params.RecordSep == "" && c.params.Align > 1
+	scanVisit := visit
+	if aligned {
+		scanVisit = nil
+	}
+
+	scanned := c.nextCut(buf, scanVisit)
+
+	cut := scanned
+	if sep := c.params.RecordSep; sep != "" {
+		cut = c.snapToRecord(buf, cut)
+	} else if align := c.params.Align; align > 1 && cut < len(buf) {
+		// Never exceed MaxSize, even for params that fail Validate
+		if limit := c.params.MaxSize / align * align; limit > 0 {
+			cut = min((cut+align-1)/align*align, limit)
+			cut = min(cut, len(buf))
+		}
+	}
+
+	switch {
+	case visit == nil:
+	case aligned:
+		visit(buf[:cut])
+	case cut > scanned:
+		// Snapping to a record may extend the chunk past the visited bytes
+		visit(buf[scanned:cut])
+	}
+	return cut
+}
+
+// snapToRecord moves cut to just after the next RecordSep starting at or
+// after cut, unless that would exceed MaxSize or the end of buf.
+func (c *Chunker) snapToRecord(buf []byte, cut int) int {
+	sep := c.params.RecordSep
+	limit := min(c.params.MaxSize, len(buf))
+	if cut >= limit {
+		return cut
+	}
+
+	i := bytes.Index(buf[cut:limit], []byte(sep))
+	if i < 0 {
+		return cut
+	}
+	return cut + i + len(sep)
+}
+
+// nextCut finds the next content-defined cut point, ignoring alignment,
+// calling visit (if non-nil) for each scanned block up to the cut.
+func (c *Chunker) nextCut(buf []byte, visit func(p []byte)) int {
 	size := 0
 	var hash uint64 = 0
 	var table *[256]uint64
@@ -92,20 +167,39 @@ func (c *Chunker) NextBoundary(buf []byte) int {
 		table = &gearTable // use default
 	}
 
-	for _, b := range buf {
-		size++
-		hash = (hash << 1) + table[b]
+	for start := 0; start < len(buf); start += scanBlock {
+		end := min(start+scanBlock, len(buf))
 
-		if size < c.params.MinSize {
-			continue
-		}
+		for _, b := range buf[start:end] {
+			size++
+			hash = (hash << 1) + table[b]
+
+			if size < c.params.MinSize {
+				continue
+			}
+
+			// Normalized chunking tests a stricter mask below AvgSize and a
+			// looser one above it; otherwise only Mask from AvgSize on
+			var found bool
+			switch {
+			case c.params.NormLevel > 0 && size < c.params.AvgSize:
+				found = hash&c.params.MaskS == 0
+			case c.params.NormLevel > 0:
+				found = hash&c.params.MaskL == 0
+			default:
+				found = size >= c.params.AvgSize && hash&c.params.Mask == 0
+			}
 
-		if size >= c.params.AvgSize && (hash&c.params.Mask) == 0 {
-			return size
+			if found || size >= c.params.MaxSize {
+				if visit != nil {
+					visit(buf[start:size])
+				}
+				return size
+			}
 		}
 
-		if size >= c.params.MaxSize {
-			return size
+		if visit != nil {
+			visit(buf[start:end])
 		}
 	}
 
@@ -2,7 +2,11 @@ package fastcdc
 
 import (
 	"bytes"
+	"fmt"
+	"math"
 	"testing"
+
+	"github.com/AumSahayata/cdcgo/datagen"
 )
 
 func TestNextBoundary_Basic(t *testing.T) {
@@ -58,3 +62,198 @@ func TestNextBoundary_Deterministic(t *testing.T) {
 		}
 	}
 }
+
+func TestNextBoundary_Aligned(t *testing.T) {
+	// Pseudo-random data so content-defined cuts land at arbitrary sizes
+	data := randomData(t, 1<<20, 1)
+
+	params := NewParams(4<<10, 16<<10, 64<<10, nil)
+	params.Align = 4096
+	chunker := NewChunker(params)
+
+	offset := 0
+	for offset < len(data) {
+		cut := chunker.NextBoundary(data[offset:])
+		if offset+cut < len(data) && cut%params.Align != 0 {
+			t.Errorf("unaligned boundary: chunk at %d has size %d", offset, cut)
+		}
+		if cut > params.MaxSize {
+			t.Errorf("chunk too big: got %d, max %d", cut, params.MaxSize)
+		}
+		offset += cut
+	}
+}
+
+func TestNextBoundaryFunc_Visit(t *testing.T) {
+	data := randomData(t, 4<<20, 7)
+
+	// MaxSize spans several scan blocks; alignment extends past the scan,
+	// or cuts below it when MaxSize is not a multiple of Align
+	for _, tt := range []struct{ min, avg, max, align int }{
+		{64 << 10, 256 << 10, 1 << 20, 0},
+		{64 << 10, 256 << 10, 1 << 20, 4096},
+		{2048, 4096, 10000, 4096},
+	} {
+		params := NewParams(tt.min, tt.avg, tt.max, nil)
+		params.Align = tt.align
+		chunker := NewChunker(params)
+		align := tt.align
+
+		offset := 0
+		for offset < len(data) {
+			var visited []byte
+			cut := chunker.NextBoundaryFunc(data[offset:], func(p []byte) {
+				visited = append(visited, p...)
+			})
+
+			if want := chunker.NextBoundary(data[offset:]); cut != want {
+				t.Fatalf("align=%d: cut %d, NextBoundary gives %d", align, cut, want)
+			}
+			if !bytes.Equal(visited, data[offset:offset+cut]) {
+				t.Fatalf("align=%d: visited %d bytes, want chunk of %d", align, len(visited), cut)
+			}
+			offset += cut
+		}
+	}
+}
+
+// dumpRows returns n SQL insert statements starting at row first.
+func dumpRows(first, n int) []byte {
+	var b bytes.Buffer
+	for i := first; i < first+n; i++ {
+		fmt.Fprintf(&b, "INSERT INTO orders VALUES (%d, 'customer-%d', %d.%02d);\n", i, i*7919%10007, i*31%997, i%100)
+	}
+	return b.Bytes()
+}
+
+// splitAll returns the chunks of data under params.
+func splitAll(data []byte, params Params) [][]byte {
+	c := NewChunker(params)
+	var chunks [][]byte
+	for off := 0; off < len(data); {
+		cut := c.NextBoundary(data[off:])
+		chunks = append(chunks, data[off:off+cut])
+		off += cut
+	}
+	return chunks
+}
+
+func TestNextBoundary_RecordSep(t *testing.T) {
+	params := SQLDumpParams()
+	data := dumpRows(0, 20000)
+
+	chunks := splitAll(data, params)
+	for i, ch := range chunks[:len(chunks)-1] {
+		if !bytes.HasSuffix(ch, []byte(params.RecordSep)) && len(ch) < params.MaxSize {
+			t.Fatalf("chunk %d (size %d) does not end on a statement", i, len(ch))
+		}
+		if len(ch) > params.MaxSize {
+			t.Fatalf("chunk %d too big: %d", i, len(ch))
+		}
+	}
+
+	// Deleting the first rows must leave almost every later chunk intact
+	seen := make(map[string]bool)
+	for _, ch := range chunks {
+		seen[string(ch)] = true
+	}
+	next := splitAll(dumpRows(50, 19950), params)
+	shared := 0
+	for _, ch := range next {
+		if seen[string(ch)] {
+			shared++
+		}
+	}
+	if shared < len(next)-3 {
+		t.Errorf("only %d of %d chunks shared after deleting leading rows", shared, len(next))
+	}
+}
+
+func TestNextBoundary_Normalized(t *testing.T) {
+	data := randomData(t, 16<<20, 11)
+
+	base := NewParams(2<<10, 8<<10, 64<<10, nil)
+	stats := func(p Params) (mean, dev float64) {
+		chunks := splitAll(data, p)
+		chunks = chunks[:len(chunks)-1] // tail size is arbitrary
+		for _, ch := range chunks {
+			mean += float64(len(ch))
+		}
+		mean /= float64(len(chunks))
+		for _, ch := range chunks {
+			dev += math.Abs(float64(len(ch)) - mean)
+		}
+		return mean, dev / float64(len(chunks))
+	}
+
+	plainMean, plainDev := stats(base)
+	avg := float64(base.AvgSize)
+	for level := 1; level <= 3; level++ {
+		p := base.Normalized(level)
+		if p.MaskS != 1<<(13+level)-1 || p.MaskL != 1<<(13-level)-1 {
+			t.Fatalf("NC%d: masks %#x/%#x", level, p.MaskS, p.MaskL)
+		}
+
+		mean, dev := stats(p)
+		if math.Abs(mean-avg) >= math.Abs(plainMean-avg) || dev >= plainDev {
+			t.Errorf("NC%d: mean %.0f dev %.0f, unnormalized mean %.0f dev %.0f", level, mean, dev, plainMean, plainDev)
+		}
+	}
+
+	// Level alone is enough; NewChunker derives the masks
+	levelOnly := base
+	levelOnly.NormLevel = 2
+	if a, b := NewChunker(levelOnly).NextBoundary(data), NewChunker(base.Normalized(2)).NextBoundary(data); a != b {
+		t.Errorf("level-only params cut at %d, Normalized(2) at %d", a, b)
+	}
+}
+
+// randomData returns n deterministic pseudo-random bytes for seed.
+func randomData(t *testing.T, n int, seed uint64) []byte {
+	t.Helper()
+
+	data, err := datagen.Bytes(datagen.Config{Size: int64(n), Seed: seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParams_Validate(t *testing.T) {
+	if err := NewParams(2<<10, 8<<10, 64<<10, nil).Validate(); err != nil {
+		t.Errorf("valid params rejected: %v", err)
+	}
+
+	aligned := NewParams(2<<10, 8<<10, 64<<10, nil)
+	aligned.Align = 4096
+	if err := aligned.Validate(); err != nil {
+		t.Errorf("aligned params rejected: %v", err)
+	}
+
+	bad := map[string]Params{
+		"zero min":        NewParams(0, 8<<10, 64<<10, nil),
+		"avg above max":   NewParams(2<<10, 128<<10, 64<<10, nil),
+		"max not aligned": {MinSize: 2048, AvgSize: 4096, MaxSize: 10000, Align: 4096},
+		"max below align": {MinSize: 1024, AvgSize: 2048, MaxSize: 3000, Align: 4096},
+		"norm level":      {MinSize: 1, AvgSize: 2, MaxSize: 4, NormLevel: 4},
+	}
+	for name, p := range bad {
+		if err := p.Validate(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestNextBoundary_AlignAboveMax(t *testing.T) {
+	data := randomData(t, 1<<20, 13)
+	params := Params{MinSize: 1024, AvgSize: 2048, MaxSize: 3000, Mask: 2047, Align: 4096}
+	chunker := NewChunker(params)
+
+	for off := 0; off < len(data); {
+		cut := chunker.NextBoundary(data[off:])
+		if cut > params.MaxSize {
+			t.Fatalf("chunk at %d has size %d > MaxSize %d", off, cut, params.MaxSize)
+		}
+		off += cut
+	}
+}
@@ -1,12 +1,47 @@
 package fastcdc
 
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+)
+
 // Params defines chunking parameters.
+//
+// Align enables hybrid fixed-alignment CDC: when > 1, every boundary except
+// the end of the input falls on a multiple of Align bytes (e.g. 4096), so
+// chunks can be written back to block storage or used with O_DIRECT without
+// re-buffering. Boundaries stay content-defined, rounded up to the next
+// aligned position. MaxSize must be a multiple of Align (see Validate).
+// chunk.ChunkReader fills its buffer before each cut, so this also holds
+// for sources that return short reads.
+//
+// RecordSep biases boundaries towards record markers for line-oriented
+// data such as SQL dumps and CSV exports: each content-defined cut is moved
+// forward to just after the next RecordSep (e.g. "\n"), if one occurs
+// before MaxSize. Chunks then hold whole records, so a shifted or edited
+// row disturbs fewer chunks. Align is ignored when RecordSep is set.
+//
+// NormLevel enables FastCDC normalized chunking (NC1–NC3, see Normalized):
+// below AvgSize boundaries are tested against the stricter MaskS, above it
+// against the looser MaskL, which concentrates chunk sizes around AvgSize
+// instead of the long tail towards MaxSize that a single Mask gives.
+// It is off by default because it changes every boundary.
 type Params struct {
 	MinSize int
 	AvgSize int
 	MaxSize int
 	Mask    uint64
 	Gear    *[256]uint64 // optional custom gear table
+	Align   int          // optional boundary alignment in bytes, 0 = none
+
+	RecordSep string // optional record marker to end chunks on, "" = none
+
+	NormLevel int    // normalized chunking level 1–3, 0 = off
+	MaskS     uint64 // mask for sizes below AvgSize when NormLevel > 0
+	MaskL     uint64 // mask for sizes from AvgSize when NormLevel > 0
 }
 
 // NewParams creates a new FastCDC parameter set.
@@ -27,3 +62,71 @@ func NewParams(min, avg, max int, gear *[256]uint64) Params {
 		Gear:    gear, // can be nil -> default table
 	}
 }
+
+// Validate reports whether p describes a usable chunker: sizes must satisfy
+// 0 < MinSize <= AvgSize <= MaxSize, MaxSize must be a multiple of Align
+// when Align > 1, and NormLevel must be 0–3.
+func (p Params) Validate() error {
+	switch {
+	case p.MinSize <= 0 || p.MinSize > p.AvgSize || p.AvgSize > p.MaxSize:
+		return fmt.Errorf("fastcdc: invalid chunk sizes %d/%d/%d", p.MinSize, p.AvgSize, p.MaxSize)
+	case p.Align < 0:
+		return fmt.Errorf("fastcdc: negative Align %d", p.Align)
+	case p.Align > 1 && p.MaxSize%p.Align != 0:
+		return fmt.Errorf("fastcdc: MaxSize %d is not a multiple of Align %d", p.MaxSize, p.Align)
+	case p.NormLevel < 0 || p.NormLevel > 3:
+		return fmt.Errorf("fastcdc: NormLevel %d out of range 0-3", p.NormLevel)
+	}
+	return nil
+}
+
+// Normalized returns a copy of p using normalized chunking at the given
+// level (1–3, clamped): MaskS gets level more bits than Mask and MaskL
+// level fewer. Level 0 turns normalization off. Level 2 is the FastCDC
+// paper's recommended setting.
+func (p Params) Normalized(level int) Params {
+	level = min(max(level, 0), 3)
+	p.NormLevel = level
+	p.MaskS, p.MaskL = 0, 0
+	if level == 0 {
+		return p
+	}
+
+	bits := bits.OnesCount64(p.Mask)
+	p.MaskS = uint64(1)<<min(bits+level, 63) - 1
+	p.MaskL = uint64(1)<<max(bits-level, 0) - 1
+	return p
+}
+
+// DumpParams returns a preset for line-oriented exports (CSV, JSON lines,
+// logs): 4 KB / 16 KB / 64 KB chunks ending on newlines.
+func DumpParams() Params {
+	p := NewParams(4<<10, 16<<10, 64<<10, nil)
+	p.RecordSep = "\n"
+	return p
+}
+
+// SQLDumpParams returns a preset for SQL dumps such as pg_dump or mysqldump
+// output: 4 KB / 16 KB / 64 KB chunks ending after a statement (";\n").
+func SQLDumpParams() Params {
+	p := NewParams(4<<10, 16<<10, 64<<10, nil)
+	p.RecordSep = ";\n"
+	return p
+}
+
+// GearID returns a short identifier for the gear table in use.
+// It is "default" for the built-in table and otherwise a hex prefix of the
+// SHA-256 of the table, so two parameter sets can be compared for
+// boundary compatibility without storing the whole table.
+func (p Params) GearID() string {
+	if p.Gear == nil {
+		return "default"
+	}
+
+	var buf [256 * 8]byte
+	for i, v := range p.Gear {
+		binary.LittleEndian.PutUint64(buf[i*8:], v)
+	}
+	sum := sha256.Sum256(buf[:])
+	return hex.EncodeToString(sum[:8])
+}
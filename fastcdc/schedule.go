@@ -0,0 +1,48 @@
+package fastcdc
+
+// SizeTier maps inputs of up to MaxInput bytes to an average chunk size.
+// A MaxInput <= 0 matches inputs of any size.
+type SizeTier struct {
+	MaxInput int64
+	AvgSize  int
+}
+
+// Schedule picks chunking parameters from the size of the input, trading
+// metadata overhead against dedup granularity: small files get small
+// chunks, very large images get large ones. Tiers are checked in order and
+// the first one whose MaxInput covers the input wins.
+type Schedule []SizeTier
+
+// DefaultSchedule is the Schedule used when none is configured.
+var DefaultSchedule = Schedule{
+	{MaxInput: 64 << 20, AvgSize: 16 << 10},  // up to 64 MB: 16 KB
+	{MaxInput: 1 << 30, AvgSize: 64 << 10},   // up to 1 GB: 64 KB
+	{MaxInput: 16 << 30, AvgSize: 256 << 10}, // up to 16 GB: 256 KB
+	{MaxInput: 64 << 30, AvgSize: 1 << 20},   // up to 64 GB: 1 MB
+	{MaxInput: 0, AvgSize: 4 << 20},          // larger: 4 MB
+}
+
+// Params returns parameters for an input of inputSize bytes, with MinSize
+// and MaxSize at a quarter and four times the chosen AvgSize. A negative
+// inputSize means the size is unknown (e.g. a pipe, see chunk.SourceSize)
+// and selects the last tier. An empty Schedule uses DefaultSchedule.
+//
+// Inputs chunked with different parameters do not dedupe against each
+// other, so record the returned parameters alongside the chunk list (or
+// use storage.NewConfig) to re-chunk consistently on the next backup.
+func (s Schedule) Params(inputSize int64, gear *[256]uint64) Params {
+	if len(s) == 0 {
+		s = DefaultSchedule
+	}
+
+	avg := s[len(s)-1].AvgSize
+	if inputSize >= 0 {
+		for _, t := range s {
+			if t.MaxInput <= 0 || inputSize <= t.MaxInput {
+				avg = t.AvgSize
+				break
+			}
+		}
+	}
+	return NewParams(avg/4, avg, avg*4, gear)
+}
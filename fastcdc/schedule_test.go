@@ -0,0 +1,31 @@
+package fastcdc
+
+import "testing"
+
+func TestSchedule_Params(t *testing.T) {
+	tests := []struct {
+		size int64
+		avg  int
+	}{
+		{0, 16 << 10},
+		{64 << 20, 16 << 10},
+		{64<<20 + 1, 64 << 10},
+		{10 << 30, 256 << 10},
+		{100 << 30, 4 << 20},
+		{-1, 4 << 20},
+	}
+	for _, tt := range tests {
+		p := DefaultSchedule.Params(tt.size, nil)
+		if p.AvgSize != tt.avg || p.MinSize != tt.avg/4 || p.MaxSize != tt.avg*4 {
+			t.Errorf("size %d: got %d/%d/%d, want avg %d", tt.size, p.MinSize, p.AvgSize, p.MaxSize, tt.avg)
+		}
+	}
+
+	custom := Schedule{{MaxInput: 1 << 20, AvgSize: 4 << 10}, {MaxInput: 1 << 30, AvgSize: 32 << 10}}
+	if p := custom.Params(2<<30, nil); p.AvgSize != 32<<10 {
+		t.Errorf("input beyond last tier: avg %d, want last tier", p.AvgSize)
+	}
+	if p := Schedule(nil).Params(1<<20, nil); p.AvgSize != 16<<10 {
+		t.Errorf("empty schedule: avg %d, want default", p.AvgSize)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package filter
+
+import "io/fs"
+
+// deviceOf is not supported on this platform; OneFileSystem is ignored.
+func deviceOf(fi fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
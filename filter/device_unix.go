@@ -0,0 +1,17 @@
+//go:build unix
+
+package filter
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// deviceOf returns the device ID of the filesystem holding fi.
+func deviceOf(fi fs.FileInfo) (uint64, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Dev), true
+}
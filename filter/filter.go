@@ -0,0 +1,219 @@
+// Package filter decides which files a backup walk includes, using
+// gitignore-style patterns, size limits, filesystem boundaries, and custom
+// predicates.
+package filter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Predicate is a custom rule. It returns false to exclude the entry.
+// rel is the slash-separated path relative to the walk root.
+type Predicate func(rel string, fi fs.FileInfo) bool
+
+// Filter is a set of include/exclude rules.
+//
+// Fields:
+//   - MaxSize:       exclude regular files larger than this (0 = no limit)
+//   - OneFileSystem: do not descend into directories on another device
+//     than the walk root (Unix only; ignored elsewhere)
+//   - Predicates:    custom rules, all of which must accept an entry
+//
+// Patterns follow .gitignore semantics: later patterns override earlier
+// ones, "!" re-includes, a trailing "/" matches directories only, a pattern
+// containing "/" is anchored at the root, and "**" matches any number of
+// directories. As with git, a file cannot be re-included once one of its
+// parent directories is excluded.
+type Filter struct {
+	MaxSize       int64
+	OneFileSystem bool
+	Predicates    []Predicate
+
+	rules []rule
+}
+
+// rule is one parsed pattern.
+type rule struct {
+	segments []string // pattern split on "/"
+	negate   bool     // "!" prefix: re-include
+	dirOnly  bool     // trailing "/": only match directories
+	anchored bool     // contains "/": match from the root only
+}
+
+// New creates a Filter from gitignore-style patterns.
+func New(patterns ...string) (*Filter, error) {
+	f := &Filter{}
+	if err := f.Add(patterns...); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Parse creates a Filter from a .gitignore-style file: one pattern per line,
+// blank lines and lines starting with "#" ignored.
+func Parse(r io.Reader) (*Filter, error) {
+	var patterns []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		patterns = append(patterns, sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return New(patterns...)
+}
+
+// Add appends patterns; they take precedence over earlier ones.
+func (f *Filter) Add(patterns ...string) error {
+	for _, p := range patterns {
+		p = strings.TrimRight(p, " \t\r")
+		if p == "" || strings.HasPrefix(p, "#") {
+			continue
+		}
+
+		var r rule
+		if strings.HasPrefix(p, "!") {
+			r.negate = true
+			p = p[1:]
+		}
+		if strings.HasSuffix(p, "/") {
+			r.dirOnly = true
+			p = strings.TrimRight(p, "/")
+		}
+		if strings.Contains(p, "/") {
+			r.anchored = true
+			p = strings.TrimPrefix(p, "/")
+		}
+		if p == "" {
+			return fmt.Errorf("filter: empty pattern")
+		}
+
+		r.segments = strings.Split(p, "/")
+		for _, seg := range r.segments {
+			if _, err := path.Match(seg, ""); err != nil {
+				return fmt.Errorf("filter: bad pattern %q: %w", p, err)
+			}
+		}
+		f.rules = append(f.rules, r)
+	}
+	return nil
+}
+
+// Include reports whether the entry at rel (slash-separated, relative to
+// the walk root) passes the patterns, size limit, and predicates. It does
+// not check parent directories; Walk prunes excluded directories itself.
+func (f *Filter) Include(rel string, fi fs.FileInfo) bool {
+	rel = strings.Trim(filepath.ToSlash(rel), "/")
+
+	excluded := false
+	for _, r := range f.rules {
+		if r.match(rel, fi.IsDir()) {
+			excluded = !r.negate
+		}
+	}
+	if excluded {
+		return false
+	}
+
+	if f.MaxSize > 0 && fi.Mode().IsRegular() && fi.Size() > f.MaxSize {
+		return false
+	}
+
+	for _, pred := range f.Predicates {
+		if !pred(rel, fi) {
+			return false
+		}
+	}
+	return true
+}
+
+// Walk walks the tree at root like filepath.WalkDir, calling fn only for
+// included entries and skipping excluded directories entirely.
+// The root itself is always visited.
+func (f *Filter) Walk(root string, fn fs.WalkDirFunc) error {
+	rootInfo, err := os.Lstat(root)
+	if err != nil {
+		return err
+	}
+	rootDev, haveDev := deviceOf(rootInfo)
+
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == root {
+			return fn(p, d, err)
+		}
+
+		rel, relErr := filepath.Rel(root, p)
+		if relErr != nil {
+			return relErr
+		}
+		fi, infoErr := d.Info()
+		if infoErr != nil {
+			return fn(p, d, infoErr)
+		}
+
+		if !f.Include(rel, fi) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() && f.OneFileSystem && haveDev {
+			if dev, ok := deviceOf(fi); ok && dev != rootDev {
+				return filepath.SkipDir
+			}
+		}
+
+		return fn(p, d, nil)
+	})
+}
+
+// match reports whether the rule matches rel.
+func (r rule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	parts := strings.Split(rel, "/")
+	if r.anchored {
+		return matchSegments(r.segments, parts)
+	}
+
+	// Unanchored patterns match the base name at any depth
+	return matchSegments(r.segments, parts[len(parts)-1:])
+}
+
+// matchSegments matches pattern segments against path segments, with "**"
+// matching zero or more path segments.
+func matchSegments(pat, parts []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			rest := pat[1:]
+			if len(rest) == 0 {
+				return len(parts) > 0
+			}
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], parts[0]); !ok {
+			return false
+		}
+		pat, parts = pat[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
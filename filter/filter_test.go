@@ -0,0 +1,143 @@
+package filter
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeInfo is a minimal fs.FileInfo for rule tests.
+type fakeInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (f fakeInfo) Name() string       { return f.name }
+func (f fakeInfo) Size() int64        { return f.size }
+func (f fakeInfo) ModTime() time.Time { return time.Time{} }
+func (f fakeInfo) IsDir() bool        { return f.dir }
+func (f fakeInfo) Sys() any           { return nil }
+func (f fakeInfo) Mode() fs.FileMode {
+	if f.dir {
+		return fs.ModeDir
+	}
+	return 0
+}
+
+// TestFilter_Patterns checks gitignore-style matching, negation, anchoring,
+// directory-only rules, and "**".
+func TestFilter_Patterns(t *testing.T) {
+	f, err := Parse(strings.NewReader(`
+# build outputs
+*.log
+!keep.log
+/tmp
+build/
+docs/**/*.pdf
+**/cache
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	tests := []struct {
+		rel  string
+		dir  bool
+		want bool
+	}{
+		{"a.txt", false, true},
+		{"a.log", false, false},
+		{"sub/deep/a.log", false, false},
+		{"keep.log", false, true},
+		{"tmp", true, false},
+		{"sub/tmp", true, true},
+		{"build", true, false},
+		{"build", false, true},
+		{"src/build", true, false},
+		{"docs/a.pdf", false, false},
+		{"docs/x/y/a.pdf", false, false},
+		{"other/a.pdf", false, true},
+		{"cache", true, false},
+		{"x/y/cache", true, false},
+	}
+	for _, tt := range tests {
+		got := f.Include(tt.rel, fakeInfo{name: filepath.Base(tt.rel), dir: tt.dir})
+		if got != tt.want {
+			t.Errorf("Include(%q, dir=%v) = %v, want %v", tt.rel, tt.dir, got, tt.want)
+		}
+	}
+}
+
+// TestFilter_SizeAndPredicates checks MaxSize and custom predicates.
+func TestFilter_SizeAndPredicates(t *testing.T) {
+	f, _ := New()
+	f.MaxSize = 100
+	f.Predicates = append(f.Predicates, func(rel string, fi fs.FileInfo) bool {
+		return !strings.HasPrefix(fi.Name(), ".")
+	})
+
+	if !f.Include("small", fakeInfo{name: "small", size: 100}) {
+		t.Error("file at the size limit should be included")
+	}
+	if f.Include("big", fakeInfo{name: "big", size: 101}) {
+		t.Error("file above the size limit should be excluded")
+	}
+	if !f.Include("bigdir", fakeInfo{name: "bigdir", size: 4096, dir: true}) {
+		t.Error("size limit should not apply to directories")
+	}
+	if f.Include(".hidden", fakeInfo{name: ".hidden"}) {
+		t.Error("predicate should exclude hidden file")
+	}
+}
+
+// TestFilter_BadPattern checks that malformed globs are rejected.
+func TestFilter_BadPattern(t *testing.T) {
+	if _, err := New("[abc"); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+}
+
+// TestFilter_Walk checks that Walk prunes excluded directories and skips
+// excluded files.
+func TestFilter_Walk(t *testing.T) {
+	root := t.TempDir()
+	for _, p := range []string{"a.txt", "b.log", "node_modules/x.js", "src/c.go", "src/d.log"} {
+		full := filepath.Join(root, p)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := New("*.log", "node_modules/", "!src/d.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	err = f.Walk(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			rel, _ := filepath.Rel(root, p)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+
+	want := []string{"a.txt", "src/c.go", "src/d.log"}
+	if !slices.Equal(got, want) {
+		t.Errorf("walked %v, want %v", got, want)
+	}
+}
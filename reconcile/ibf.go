@@ -0,0 +1,261 @@
+// Package reconcile lets two repositories compute the difference of their
+// chunk sets while exchanging data proportional to the difference, not to
+// the size of the sets, using invertible Bloom lookup tables (IBFs).
+//
+// Typical exchange:
+//
+//  1. The receiver builds an IBF over its chunk hashes with FromHashes and
+//     sends the MarshalBinary output to the sender.
+//  2. The sender calls Difference with its own hashes and the received IBF
+//     and gets the hashes only it has (to send) and only the receiver has.
+//  3. If Difference returns ErrDecodeFailed, the difference was larger than
+//     the IBF can hold; retry with more cells (e.g. doubled).
+package reconcile
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+)
+
+// ErrDecodeFailed is returned when an IBF is too small for the difference
+// it holds. Retry with a larger table.
+var ErrDecodeFailed = errors.New("reconcile: IBF too small to decode difference")
+
+// numHashes is the number of cells each key is stored in.
+const numHashes = 3
+
+// MaxKeySize is the largest key size accepted from encoded tables, enough
+// for SHA-512 hashes.
+const MaxKeySize = 64
+
+// cell is one IBF bucket.
+type cell struct {
+	count   int32
+	keySum  []byte // XOR of all keys in the cell
+	hashSum uint64 // XOR of checksums of all keys in the cell
+}
+
+// IBF is an invertible Bloom lookup table over fixed-size keys.
+type IBF struct {
+	cells   []cell
+	keySize int
+}
+
+// CellsFor returns a table size able to decode a difference of about diff
+// keys with high probability.
+func CellsFor(diff int) int {
+	n := 2*diff + 16
+	// Round up to a multiple of numHashes so partitions are equal
+	return (n + numHashes - 1) / numHashes * numHashes
+}
+
+// NewIBF creates an empty IBF with the given number of cells (rounded up to
+// a multiple of 3) for keys of keySize bytes (e.g. 32 for SHA-256).
+func NewIBF(cells, keySize int) *IBF {
+	cells = max(cells, numHashes)
+	cells = (cells + numHashes - 1) / numHashes * numHashes
+
+	f := &IBF{cells: make([]cell, cells), keySize: keySize}
+	for i := range f.cells {
+		f.cells[i].keySum = make([]byte, keySize)
+	}
+	return f
+}
+
+// FromHashes builds an IBF over hex-encoded chunk hashes (as used as index
+// keys). All hashes must decode to the same length.
+func FromHashes(hashes []string, cells int) (*IBF, error) {
+	var f *IBF
+	for _, h := range hashes {
+		key, err := hex.DecodeString(h)
+		if err != nil {
+			return nil, fmt.Errorf("reconcile: bad hash %q: %w", h, err)
+		}
+		if f == nil {
+			f = NewIBF(cells, len(key))
+		}
+		if err := f.Insert(key); err != nil {
+			return nil, err
+		}
+	}
+	if f == nil {
+		f = NewIBF(cells, 32)
+	}
+	return f, nil
+}
+
+// Insert adds key to the table.
+func (f *IBF) Insert(key []byte) error {
+	if len(key) != f.keySize {
+		return fmt.Errorf("reconcile: key size %d, want %d", len(key), f.keySize)
+	}
+	f.toggle(key, 1)
+	return nil
+}
+
+// Subtract returns f minus other. Keys present in both cancel out, leaving
+// only the symmetric difference. Both tables must have the same shape.
+func (f *IBF) Subtract(other *IBF) (*IBF, error) {
+	if len(f.cells) != len(other.cells) || f.keySize != other.keySize {
+		return nil, fmt.Errorf("reconcile: shape mismatch: %d/%d cells, %d/%d key bytes",
+			len(f.cells), len(other.cells), f.keySize, other.keySize)
+	}
+
+	out := NewIBF(len(f.cells), f.keySize)
+	for i := range f.cells {
+		a, b, c := &f.cells[i], &other.cells[i], &out.cells[i]
+		c.count = a.count - b.count
+		c.hashSum = a.hashSum ^ b.hashSum
+		for j := range c.keySum {
+			c.keySum[j] = a.keySum[j] ^ b.keySum[j]
+		}
+	}
+	return out, nil
+}
+
+// Decode lists the keys of a subtracted table: onlyA were inserted into the
+// minuend only, onlyB into the subtrahend only. It consumes the table.
+// Returns ErrDecodeFailed if the table cannot be fully decoded.
+//
+// The table may come from a peer, so the work is bounded: a key peeled
+// twice, or more peels than cells, means the table is inconsistent.
+func (f *IBF) Decode() (onlyA, onlyB [][]byte, err error) {
+	peeled := make(map[string]bool)
+	for progress := true; progress; {
+		progress = false
+		for i := range f.cells {
+			c := &f.cells[i]
+			if (c.count != 1 && c.count != -1) || checksum(c.keySum) != c.hashSum {
+				continue // not a pure cell
+			}
+			if peeled[string(c.keySum)] || len(peeled) >= len(f.cells) {
+				return onlyA, onlyB, ErrDecodeFailed
+			}
+			peeled[string(c.keySum)] = true
+
+			key := append([]byte(nil), c.keySum...)
+			if c.count == 1 {
+				onlyA = append(onlyA, key)
+			} else {
+				onlyB = append(onlyB, key)
+			}
+			f.toggle(key, -c.count)
+			progress = true
+		}
+	}
+
+	for _, c := range f.cells {
+		if c.count != 0 || c.hashSum != 0 {
+			return onlyA, onlyB, ErrDecodeFailed
+		}
+	}
+	return onlyA, onlyB, nil
+}
+
+// Difference compares local hex hashes with a remote IBF (built with the
+// same number of cells) and returns the hashes only present locally and
+// those only present remotely.
+func Difference(local []string, remote *IBF) (localOnly, remoteOnly []string, err error) {
+	mine := NewIBF(len(remote.cells), remote.keySize)
+	for _, h := range local {
+		key, err := hex.DecodeString(h)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reconcile: bad hash %q: %w", h, err)
+		}
+		if err := mine.Insert(key); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	diff, err := mine.Subtract(remote)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	a, b, err := diff.Decode()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, k := range a {
+		localOnly = append(localOnly, hex.EncodeToString(k))
+	}
+	for _, k := range b {
+		remoteOnly = append(remoteOnly, hex.EncodeToString(k))
+	}
+	return localOnly, remoteOnly, nil
+}
+
+// MarshalBinary encodes the table for transmission.
+func (f *IBF) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 8, 8+len(f.cells)*(12+f.keySize))
+	binary.BigEndian.PutUint32(buf[0:], uint32(len(f.cells)))
+	binary.BigEndian.PutUint32(buf[4:], uint32(f.keySize))
+
+	for _, c := range f.cells {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(c.count))
+		buf = binary.BigEndian.AppendUint64(buf, c.hashSum)
+		buf = append(buf, c.keySum...)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a table produced by MarshalBinary.
+func (f *IBF) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return fmt.Errorf("reconcile: IBF data too short")
+	}
+	cells := int64(binary.BigEndian.Uint32(data[0:]))
+	keySize := int64(binary.BigEndian.Uint32(data[4:]))
+
+	// Validate the header against the payload before allocating anything,
+	// since it comes from a peer
+	if cells == 0 || cells%numHashes != 0 || keySize == 0 || keySize > MaxKeySize ||
+		int64(len(data)) != 8+cells*(12+keySize) {
+		return fmt.Errorf("reconcile: malformed IBF data (%d cells, %d key bytes, %d bytes)", cells, keySize, len(data))
+	}
+
+	*f = *NewIBF(int(cells), int(keySize))
+	off := 8
+	for i := range f.cells {
+		c := &f.cells[i]
+		c.count = int32(binary.BigEndian.Uint32(data[off:]))
+		c.hashSum = binary.BigEndian.Uint64(data[off+4:])
+		copy(c.keySum, data[off+12:off+12+int(keySize)])
+		off += 12 + int(keySize)
+	}
+	return nil
+}
+
+// toggle adds (delta=1) or removes (delta=-1) key from its cells.
+func (f *IBF) toggle(key []byte, delta int32) {
+	sum := checksum(key)
+	part := len(f.cells) / numHashes
+
+	for i := range numHashes {
+		c := &f.cells[i*part+int(position(key, i)%uint64(part))]
+		c.count += delta
+		c.hashSum ^= sum
+		for j := range c.keySum {
+			c.keySum[j] ^= key[j]
+		}
+	}
+}
+
+// position hashes key for the i-th partition.
+func position(key []byte, i int) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte{byte(i)})
+	h.Write(key)
+	return h.Sum64()
+}
+
+// checksum is an independent hash of key used to detect pure cells.
+func checksum(key []byte) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte("cdcgo-ibf-check"))
+	h.Write(key)
+	return h.Sum64()
+}
@@ -0,0 +1,116 @@
+package reconcile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// hashes returns hex SHA-256 hashes of "prefix-i" for i in [from, to).
+func hashes(prefix string, from, to int) []string {
+	var out []string
+	for i := from; i < to; i++ {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s-%d", prefix, i)))
+		out = append(out, hex.EncodeToString(sum[:]))
+	}
+	return out
+}
+
+// TestDifference verifies that large, mostly-shared sets reconcile to the
+// exact symmetric difference through a serialized IBF.
+func TestDifference(t *testing.T) {
+	shared := hashes("shared", 0, 10000)
+	local := append(slices.Clone(shared), hashes("local", 0, 40)...)
+	remote := append(slices.Clone(shared), hashes("remote", 0, 25)...)
+
+	remoteIBF, err := FromHashes(remote, CellsFor(65))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Simulate the network hop
+	wire, _ := remoteIBF.MarshalBinary()
+	var received IBF
+	if err := received.UnmarshalBinary(wire); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	localOnly, remoteOnly, err := Difference(local, &received)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := hashes("local", 0, 40)
+	slices.Sort(want)
+	slices.Sort(localOnly)
+	if !slices.Equal(localOnly, want) {
+		t.Errorf("local-only = %d hashes, want %d", len(localOnly), len(want))
+	}
+
+	want = hashes("remote", 0, 25)
+	slices.Sort(want)
+	slices.Sort(remoteOnly)
+	if !slices.Equal(remoteOnly, want) {
+		t.Errorf("remote-only = %d hashes, want %d", len(remoteOnly), len(want))
+	}
+}
+
+// TestDifference_TooSmall verifies that an undersized table reports
+// ErrDecodeFailed instead of a wrong answer.
+func TestDifference_TooSmall(t *testing.T) {
+	remote, _ := FromHashes(hashes("remote", 0, 500), 12)
+	if _, _, err := Difference(hashes("local", 0, 500), remote); !errors.Is(err, ErrDecodeFailed) {
+		t.Errorf("expected ErrDecodeFailed, got %v", err)
+	}
+}
+
+// TestUnmarshalBinary_Malformed verifies input validation.
+func TestUnmarshalBinary_Malformed(t *testing.T) {
+	tests := map[string][]byte{
+		"truncated":       {0, 0, 0, 3, 0, 0, 0, 32, 1},
+		"short header":    {0, 0, 0},
+		"zero cells":      make([]byte, 8),
+		"zero key size":   append([]byte{0, 0, 0, 3, 0, 0, 0, 0}, make([]byte, 36)...),
+		"huge key size":   {0, 0, 0, 3, 0xff, 0xff, 0xff, 0xff},
+		"huge cell count": {0xff, 0xff, 0xff, 0xfc, 0, 0, 0, 32},
+	}
+	for name, data := range tests {
+		var f IBF
+		if err := f.UnmarshalBinary(data); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// TestDecode_Cyclic verifies that a crafted table whose peeling would
+// ping-pong a key between two cells fails instead of looping forever.
+func TestDecode_Cyclic(t *testing.T) {
+	key := make([]byte, 32)
+	key[0] = 0x5a
+
+	remote := NewIBF(CellsFor(10), len(key))
+	part := len(remote.cells) / numHashes
+	c := &remote.cells[int(position(key, 0)%uint64(part))]
+	c.count = 1 // Difference subtracts remote, so the local view is -1
+	copy(c.keySum, key)
+	c.hashSum = checksum(key)
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := Difference(nil, remote)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrDecodeFailed) {
+			t.Errorf("expected ErrDecodeFailed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Decode did not terminate")
+	}
+}
@@ -0,0 +1,211 @@
+// Package simulate measures how well a set of FastCDC parameters localizes
+// edits: it applies synthetic edits to an input, re-chunks it, and reports
+// how many chunks changed.
+package simulate
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/rand/v2"
+	"os"
+
+	"github.com/AumSahayata/cdcgo/fastcdc"
+)
+
+// EditKind selects the type of synthetic edit.
+type EditKind int
+
+const (
+	Insert EditKind = iota // insert random bytes
+	Delete                 // remove bytes
+	Modify                 // overwrite bytes in place
+	Mixed                  // pick Insert, Delete, or Modify at random per edit
+)
+
+// String implements fmt.Stringer.
+func (k EditKind) String() string {
+	switch k {
+	case Insert:
+		return "insert"
+	case Delete:
+		return "delete"
+	case Modify:
+		return "modify"
+	case Mixed:
+		return "mixed"
+	}
+	return fmt.Sprintf("EditKind(%d)", int(k))
+}
+
+// Edit describes one applied edit.
+type Edit struct {
+	Kind   EditKind
+	Offset int // position in the data at the time the edit was applied
+	Length int // bytes inserted, deleted, or modified
+}
+
+// Options configures a simulation.
+//
+// Fields:
+//   - Params:   chunking parameters under test
+//   - Edits:    number of edits to apply
+//   - EditSize: bytes per edit
+//   - Kind:     type of edit
+//   - Seed:     random seed; the same seed and input give the same report
+type Options struct {
+	Params   fastcdc.Params
+	Edits    int
+	EditSize int
+	Kind     EditKind
+	Seed     uint64
+}
+
+// Report summarizes the effect of the edits on chunking.
+//
+// Fields:
+//   - OriginalChunks: chunks in the unedited input
+//   - EditedChunks:   chunks in the edited input
+//   - ChangedChunks:  edited chunks whose content is not in the original
+//   - ChangedBytes:   total size of the changed chunks
+//   - EditedBytes:    total bytes touched by the edits
+//   - Edits:          the edits that were applied, in order
+type Report struct {
+	OriginalChunks int
+	EditedChunks   int
+	ChangedChunks  int
+	ChangedBytes   int64
+	EditedBytes    int64
+	Edits          []Edit
+}
+
+// Amplification returns ChangedBytes / EditedBytes: how many bytes of new
+// chunk data each edited byte costs. Lower is better; 0 if nothing changed.
+func (r Report) Amplification() float64 {
+	if r.EditedBytes == 0 {
+		return 0
+	}
+	return float64(r.ChangedBytes) / float64(r.EditedBytes)
+}
+
+// String implements fmt.Stringer for convenient printing.
+func (r Report) String() string {
+	return fmt.Sprintf("Report {edits=%d, chunks=%d->%d, changed=%d (%d bytes), amplification=%.2f}",
+		len(r.Edits), r.OriginalChunks, r.EditedChunks, r.ChangedChunks, r.ChangedBytes, r.Amplification())
+}
+
+// SimulateFile reads the file at path and runs Simulate on its content.
+func SimulateFile(path string, opts Options) (Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Report{}, err
+	}
+	return Simulate(data, opts)
+}
+
+// Simulate chunks data, applies opts.Edits synthetic edits at random
+// positions, re-chunks the result, and reports how many chunks changed.
+// data is not modified.
+func Simulate(data []byte, opts Options) (Report, error) {
+	if opts.EditSize <= 0 {
+		return Report{}, fmt.Errorf("simulate: edit size must be positive, got %d", opts.EditSize)
+	}
+	if opts.Params.MaxSize <= 0 {
+		return Report{}, fmt.Errorf("simulate: invalid chunking params: max size %d", opts.Params.MaxSize)
+	}
+
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15))
+	chunker := fastcdc.NewChunker(opts.Params)
+
+	original := chunkHashes(chunker, data)
+	known := make(map[[sha256.Size]byte]struct{}, len(original))
+	for _, c := range original {
+		known[c.hash] = struct{}{}
+	}
+
+	edited := append([]byte(nil), data...)
+	report := Report{OriginalChunks: len(original)}
+
+	for range opts.Edits {
+		kind := opts.Kind
+		if kind == Mixed {
+			kind = EditKind(rng.IntN(3))
+		}
+
+		edit, ok := applyEdit(rng, &edited, kind, opts.EditSize)
+		if !ok {
+			break // nothing left to delete or modify
+		}
+		report.Edits = append(report.Edits, edit)
+		report.EditedBytes += int64(edit.Length)
+	}
+
+	for _, c := range chunkHashes(chunker, edited) {
+		report.EditedChunks++
+		if _, ok := known[c.hash]; !ok {
+			report.ChangedChunks++
+			report.ChangedBytes += int64(c.size)
+		}
+	}
+
+	return report, nil
+}
+
+// applyEdit applies one edit of the given kind to *data in place.
+// It returns false if the edit cannot be applied (e.g. data is empty).
+func applyEdit(rng *rand.Rand, data *[]byte, kind EditKind, size int) (Edit, bool) {
+	d := *data
+
+	switch kind {
+	case Insert:
+		off := rng.IntN(len(d) + 1)
+		ins := make([]byte, size)
+		fillRandom(rng, ins)
+		d = append(d[:off], append(ins, d[off:]...)...)
+		*data = d
+		return Edit{Kind: Insert, Offset: off, Length: size}, true
+
+	case Delete:
+		if len(d) == 0 {
+			return Edit{}, false
+		}
+		n := min(size, len(d))
+		off := rng.IntN(len(d) - n + 1)
+		*data = append(d[:off], d[off+n:]...)
+		return Edit{Kind: Delete, Offset: off, Length: n}, true
+
+	case Modify:
+		if len(d) == 0 {
+			return Edit{}, false
+		}
+		n := min(size, len(d))
+		off := rng.IntN(len(d) - n + 1)
+		fillRandom(rng, d[off:off+n])
+		return Edit{Kind: Modify, Offset: off, Length: n}, true
+	}
+
+	return Edit{}, false
+}
+
+// fillRandom fills p with pseudo-random bytes from rng.
+func fillRandom(rng *rand.Rand, p []byte) {
+	for i := range p {
+		p[i] = byte(rng.Uint32())
+	}
+}
+
+// chunkSum is the size and content hash of one chunk.
+type chunkSum struct {
+	size int
+	hash [sha256.Size]byte
+}
+
+// chunkHashes splits data with chunker and hashes each chunk.
+func chunkHashes(chunker *fastcdc.Chunker, data []byte) []chunkSum {
+	var out []chunkSum
+	for off := 0; off < len(data); {
+		cut := chunker.NextBoundary(data[off:])
+		out = append(out, chunkSum{size: cut, hash: sha256.Sum256(data[off : off+cut])})
+		off += cut
+	}
+	return out
+}
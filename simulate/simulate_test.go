@@ -0,0 +1,72 @@
+package simulate
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/AumSahayata/cdcgo/fastcdc"
+)
+
+// randomData returns n deterministic pseudo-random bytes.
+func randomData(n int) []byte {
+	rng := rand.New(rand.NewPCG(1, 2))
+	data := make([]byte, n)
+	fillRandom(rng, data)
+	return data
+}
+
+// TestSimulate_LocalizedEdits verifies that a few small edits only change a
+// handful of chunks, for every edit kind.
+func TestSimulate_LocalizedEdits(t *testing.T) {
+	data := randomData(1 << 20)
+	params := fastcdc.NewParams(1<<10, 4<<10, 16<<10, nil)
+
+	for _, kind := range []EditKind{Insert, Delete, Modify, Mixed} {
+		t.Run(kind.String(), func(t *testing.T) {
+			r, err := Simulate(data, Options{Params: params, Edits: 5, EditSize: 16, Kind: kind, Seed: 42})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(r.Edits) != 5 {
+				t.Errorf("edits applied = %d, want 5", len(r.Edits))
+			}
+			if r.ChangedChunks == 0 {
+				t.Errorf("expected some chunks to change")
+			}
+			// Each edit should disturb at most a few neighbouring chunks
+			if r.ChangedChunks > 5*3 {
+				t.Errorf("edits not localized: %d of %d chunks changed", r.ChangedChunks, r.EditedChunks)
+			}
+		})
+	}
+}
+
+// TestSimulate_Deterministic verifies that the same seed gives the same report.
+func TestSimulate_Deterministic(t *testing.T) {
+	data := randomData(256 << 10)
+	opts := Options{
+		Params:   fastcdc.NewParams(512, 2<<10, 8<<10, nil),
+		Edits:    10,
+		EditSize: 32,
+		Kind:     Mixed,
+		Seed:     7,
+	}
+
+	r1, err := Simulate(data, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r2, _ := Simulate(data, opts)
+
+	if r1.String() != r2.String() {
+		t.Errorf("reports differ:\n%v\n%v", r1, r2)
+	}
+}
+
+// TestSimulate_InvalidOptions verifies option validation.
+func TestSimulate_InvalidOptions(t *testing.T) {
+	if _, err := Simulate([]byte("data"), Options{Params: fastcdc.NewParams(1, 2, 4, nil)}); err == nil {
+		t.Errorf("expected error for zero edit size")
+	}
+}
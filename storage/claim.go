@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// ClaimIndex is implemented by indices that can coordinate uploads of new
+// chunks between many writers sharing them.
+//
+// A claim is a lease on a hash: while it is held, other writers should wait
+// for the chunk to appear instead of uploading it themselves. Leases expire
+// after their ttl so a crashed uploader does not block the chunk forever.
+// Claim returns an owner token for the lease, and Release only drops the
+// lease while that token still owns it, so a slow writer whose lease
+// expired cannot release the lease another writer has since taken.
+type ClaimIndex interface {
+	Index
+	Claim(hash string, ttl time.Duration) (token string, err error) // try to take the lease; "" if held by another writer
+	Release(hash, token string) error                               // drop the lease if token still owns it
+}
+
+// newLeaseToken returns a random owner token for a lease.
+func newLeaseToken() string {
+	return rand.Text()
+}
+
+// UploadOnce makes sure ch is uploaded exactly once across all writers
+// sharing idx.
+//
+// If the chunk is already indexed it returns (false, nil). If this caller
+// wins the claim, upload is run, the chunk is added to idx, and the claim is
+// released; it returns true once the chunk is uploaded and indexed.
+// Otherwise it polls every poll interval until another writer has indexed
+// the chunk, or retries the claim once the other writer's lease has
+// expired. ctx cancels the wait.
+func UploadOnce(ctx context.Context, idx ClaimIndex, ch types.Chunk, ttl, poll time.Duration, upload func() error) (uploaded bool, err error) {
+	key := ch.HexHash()
+
+	for {
+		if idx.Exists(key) {
+			return false, nil
+		}
+
+		token, err := idx.Claim(key, ttl)
+		if err != nil {
+			return false, err
+		}
+		if token != "" {
+			return uploadClaimed(idx, ch, key, token, upload)
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(poll):
+		}
+	}
+}
+
+// uploadClaimed runs upload under a held claim and records the chunk.
+func uploadClaimed(idx ClaimIndex, ch types.Chunk, key, token string, upload func() error) (bool, error) {
+	defer idx.Release(key, token)
+
+	// Another writer may have finished between our existence check and claim
+	if idx.Exists(key) {
+		return false, nil
+	}
+	if err := upload(); err != nil {
+		return false, fmt.Errorf("upload %s: %w", key, err)
+	}
+	if err := idx.Add(ch); err != nil {
+		return true, fmt.Errorf("index %s: %w", key, err)
+	}
+	return true, nil
+}
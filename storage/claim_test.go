@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// runUploaders starts n concurrent UploadOnce calls for the same chunk,
+// spread over the given indices, and returns how many uploads ran.
+func runUploaders(t *testing.T, n int, indices ...ClaimIndex) int64 {
+	t.Helper()
+
+	ch := helperChunk([]byte("popular chunk"), 13)
+	var uploads atomic.Int64
+	var wg sync.WaitGroup
+
+	for i := range n {
+		idx := indices[i%len(indices)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := UploadOnce(context.Background(), idx, ch, time.Minute, time.Millisecond, func() error {
+				uploads.Add(1)
+				time.Sleep(20 * time.Millisecond) // simulate transfer
+				return nil
+			})
+			if err != nil {
+				t.Errorf("upload failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, idx := range indices {
+		if !idx.Exists(ch.HexHash()) {
+			t.Errorf("chunk not indexed after upload")
+		}
+	}
+	return uploads.Load()
+}
+
+// TestUploadOnce_MemoryIndex verifies that concurrent writers sharing a
+// MemoryIndex upload a new chunk only once.
+func TestUploadOnce_MemoryIndex(t *testing.T) {
+	if n := runUploaders(t, 20, NewMemoryIndex()); n != 1 {
+		t.Errorf("uploads = %d, want 1", n)
+	}
+}
+
+// TestUploadOnce_SharedJSONInstance verifies that goroutines sharing one
+// PersistentIndexJSON upload once even without cross-process file locks.
+// SetLogger runs concurrently to catch races on the logger.
+func TestUploadOnce_SharedJSONInstance(t *testing.T) {
+	idx, err := NewPersistentIndexJSON(t.TempDir() + "/index.json")
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 50 {
+			idx.SetLogger(nil)
+		}
+	}()
+
+	if n := runUploaders(t, 10, idx); n != 1 {
+		t.Errorf("uploads = %d, want 1", n)
+	}
+	<-done
+}
+
+// TestUploadOnce_PersistentIndexJSON verifies the same across two index
+// instances sharing one file, as separate processes would.
+func TestUploadOnce_PersistentIndexJSON(t *testing.T) {
+	if !haveFileLock {
+		t.Skip("no cross-process file locking on this platform")
+	}
+
+	path := t.TempDir() + "/index.json"
+	a, err := NewPersistentIndexJSON(path)
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+	b, err := NewPersistentIndexJSON(path)
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+
+	if n := runUploaders(t, 10, a, b); n != 1 {
+		t.Errorf("uploads = %d, want 1", n)
+	}
+}
+
+// TestClaim_Expiry verifies that an expired lease can be taken over, and
+// that the previous owner's Release then leaves the new lease in place.
+func TestClaim_Expiry(t *testing.T) {
+	idx, err := NewPersistentIndexJSON(t.TempDir() + "/index.json")
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+
+	for name, ci := range map[string]ClaimIndex{"memory": NewMemoryIndex(), "json": idx} {
+		hash := helperChunk([]byte("lease"), 5).HexHash()
+
+		first, err := ci.Claim(hash, 10*time.Millisecond)
+		if first == "" || err != nil {
+			t.Fatalf("%s: first claim failed: %q, %v", name, first, err)
+		}
+		if token, _ := ci.Claim(hash, time.Minute); token != "" {
+			t.Fatalf("%s: claim should be held", name)
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		second, err := ci.Claim(hash, time.Minute)
+		if second == "" || err != nil {
+			t.Fatalf("%s: expired claim not taken over: %q, %v", name, second, err)
+		}
+
+		// The slow first writer finishes and releases its stale lease
+		if err := ci.Release(hash, first); err != nil {
+			t.Fatalf("%s: release failed: %v", name, err)
+		}
+		if token, _ := ci.Claim(hash, time.Minute); token != "" {
+			t.Errorf("%s: stale release dropped the new owner's lease", name)
+		}
+
+		if err := ci.Release(hash, second); err != nil {
+			t.Fatalf("%s: release failed: %v", name, err)
+		}
+		if token, _ := ci.Claim(hash, time.Minute); token == "" {
+			t.Errorf("%s: owner's release did not drop the lease", name)
+		}
+	}
+
+	if _, err := idx.Claim("../escape", time.Minute); err == nil {
+		t.Errorf("expected invalid hash to be rejected")
+	}
+}
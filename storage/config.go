@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+
+	"github.com/AumSahayata/cdcgo/fastcdc"
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// ConfigVersion is the current repository config format version.
+const ConfigVersion = 1
+
+// Config records the settings every writer of a chunk repository must agree
+// on. Chunks written with different chunking parameters, gear table, or hash
+// algorithm never dedupe against each other, so the config is stored next to
+// the chunks (e.g. chunks/cdcgo-config.json) and enforced on open.
+type Config struct {
+	Version     int
+	MinSize     int
+	AvgSize     int
+	MaxSize     int
+	Mask        uint64 `json:",omitempty"` // see fastcdc.Params.Mask
+	Align       int    `json:",omitempty"` // see fastcdc.Params.Align
+	RecordSep   string `json:",omitempty"` // see fastcdc.Params.RecordSep
+	NormLevel   int    `json:",omitempty"` // see fastcdc.Params.NormLevel
+	GearID      string // see fastcdc.Params.GearID
+	Hash        string // hash algorithm name, see NewHasher
+	Compression string `json:",omitempty"` // codec name, empty if none
+	Encryption  string `json:",omitempty"` // cipher name, empty if none
+}
+
+// NewConfig builds a Config from chunking parameters and a hash name.
+func NewConfig(params fastcdc.Params, hashName string) Config {
+	return Config{
+		Version:   ConfigVersion,
+		MinSize:   params.MinSize,
+		AvgSize:   params.AvgSize,
+		MaxSize:   params.MaxSize,
+		Mask:      params.Mask,
+		Align:     params.Align,
+		RecordSep: params.RecordSep,
+		NormLevel: params.NormLevel,
+		GearID:    params.GearID(),
+		Hash:      hashName,
+	}
+}
+
+// LoadConfig reads a Config from path.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, fmt.Errorf("read config %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("parse config %s: %w", path, err)
+	}
+
+	// Configs written before Mask was recorded used the default mask
+	if c.Mask == 0 {
+		c.Mask = fastcdc.NewParams(c.MinSize, c.AvgSize, c.MaxSize, nil).Mask
+	}
+	if err := c.Validate(); err != nil {
+		return c, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Validate reports whether c looks like a usable repository config: a
+// known version and hash algorithm, and chunking parameters accepted by
+// fastcdc.Params.Validate.
+func (c Config) Validate() error {
+	if c.Version < 1 || c.Version > ConfigVersion {
+		return fmt.Errorf("unsupported config version %d (want 1 to %d)", c.Version, ConfigVersion)
+	}
+	if _, err := NewHasher(c.Hash); err != nil {
+		return err
+	}
+	p := fastcdc.Params{MinSize: c.MinSize, AvgSize: c.AvgSize, MaxSize: c.MaxSize, Align: c.Align, NormLevel: c.NormLevel}
+	return p.Validate()
+}
+
+// Save writes the Config to path atomically via a synced temp file and
+// rename.
+func (c Config) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", " ")
+	if err != nil {
+		return fmt.Errorf("save config %s: %w", path, err)
+	}
+
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("save config %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("save config %s: %w", path, err)
+	}
+	if err := f.Sync(); err != nil { // ensure durability
+		return fmt.Errorf("save config %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("save config %s: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("save config %s: %w", path, err)
+	}
+	return nil
+}
+
+// Check reports an error wrapping ErrConfigMismatch for the first setting
+// in want that differs from c.
+func (c Config) Check(want Config) error {
+	switch {
+	case c.Version > ConfigVersion:
+		return fmt.Errorf("%w: version %d is newer than supported %d", ErrConfigMismatch, c.Version, ConfigVersion)
+	case c.MinSize != want.MinSize || c.AvgSize != want.AvgSize || c.MaxSize != want.MaxSize:
+		return fmt.Errorf("%w: chunk sizes %d/%d/%d, want %d/%d/%d", ErrConfigMismatch,
+			c.MinSize, c.AvgSize, c.MaxSize, want.MinSize, want.AvgSize, want.MaxSize)
+	case c.Mask != want.Mask:
+		return fmt.Errorf("%w: mask %#x, want %#x", ErrConfigMismatch, c.Mask, want.Mask)
+	case c.Align != want.Align:
+		return fmt.Errorf("%w: alignment %d, want %d", ErrConfigMismatch, c.Align, want.Align)
+	case c.RecordSep != want.RecordSep:
+		return fmt.Errorf("%w: record separator %q, want %q", ErrConfigMismatch, c.RecordSep, want.RecordSep)
+	case c.NormLevel != want.NormLevel:
+		return fmt.Errorf("%w: normalization level %d, want %d", ErrConfigMismatch, c.NormLevel, want.NormLevel)
+	case c.GearID != want.GearID:
+		return fmt.Errorf("%w: gear table %s, want %s", ErrConfigMismatch, c.GearID, want.GearID)
+	case c.Hash != want.Hash:
+		return fmt.Errorf("%w: hash %s, want %s", ErrConfigMismatch, c.Hash, want.Hash)
+	case c.Compression != want.Compression:
+		return fmt.Errorf("%w: compression %q, want %q", ErrConfigMismatch, c.Compression, want.Compression)
+	case c.Encryption != want.Encryption:
+		return fmt.Errorf("%w: encryption %q, want %q", ErrConfigMismatch, c.Encryption, want.Encryption)
+	}
+	return nil
+}
+
+// CheckChunk reports an error wrapping ErrConfigMismatch if ch cannot have
+// been produced under c: it is larger than MaxSize or its hash length does
+// not match the configured algorithm.
+func (c Config) CheckChunk(ch types.Chunk) error {
+	if c.MaxSize > 0 && ch.Size > c.MaxSize {
+		return fmt.Errorf("%w: chunk %s has %d bytes, max %d", ErrConfigMismatch, ch.HexHash(), ch.Size, c.MaxSize)
+	}
+
+	h, err := NewHasher(c.Hash)
+	if err != nil {
+		return err
+	}
+	if len(ch.Hash) != h.Size() {
+		return fmt.Errorf("%w: chunk %s has a %d byte hash, %s uses %d", ErrConfigMismatch, ch.HexHash(), len(ch.Hash), c.Hash, h.Size())
+	}
+	return nil
+}
+
+// ConfigIndex is implemented by indices that carry the repository Config,
+// so writers can refuse chunks that were produced with other settings
+// (see chunk.ChunkWriter).
+type ConfigIndex interface {
+	Index
+	Config() (Config, bool)                   // the config in effect, if any
+	EnsureConfig(want Config) (Config, error) // adopt want, or check it against the existing config
+}
+
+// EnsureConfig creates the config at path from want if none exists yet,
+// otherwise loads it and checks it against want.
+//
+// Returns the config in effect for the repository.
+func EnsureConfig(path string, want Config) (Config, error) {
+	if _, err := NewHasher(want.Hash); err != nil {
+		return Config{}, err
+	}
+
+	c, err := LoadConfig(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if err := want.Save(path); err != nil {
+			return Config{}, err
+		}
+		return want, nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+
+	if err := c.Check(want); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// NewHasher returns a new hash.Hash for a hash algorithm name recorded in
+// a Config ("sha1", "sha256", "sha512"). Unknown names return an error
+// wrapping ErrUnsupportedHash.
+func NewHasher(name string) (hash.Hash, error) {
+	switch name {
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedHash, name)
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AumSahayata/cdcgo/fastcdc"
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// TestEnsureConfig_CreateAndEnforce verifies that the first open creates the
+// config and later opens with different settings are rejected.
+func TestEnsureConfig_CreateAndEnforce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	want := NewConfig(fastcdc.NewParams(2<<10, 8<<10, 64<<10, nil), "sha256")
+
+	if _, err := EnsureConfig(path, want); err != nil {
+		t.Fatalf("failed to create config: %v", err)
+	}
+
+	// Same settings → accepted
+	got, err := EnsureConfig(path, want)
+	if err != nil {
+		t.Fatalf("reopen with same settings failed: %v", err)
+	}
+	if got != want {
+		t.Errorf("loaded config = %+v, want %+v", got, want)
+	}
+
+	// Different chunk sizes → rejected
+	other := NewConfig(fastcdc.NewParams(4<<10, 16<<10, 64<<10, nil), "sha256")
+	if _, err := EnsureConfig(path, other); !errors.Is(err, ErrConfigMismatch) {
+		t.Errorf("expected ErrConfigMismatch for chunk sizes, got %v", err)
+	}
+
+	// Different gear table → rejected
+	var gear [256]uint64
+	gear[0] = 1
+	other = NewConfig(fastcdc.NewParams(2<<10, 8<<10, 64<<10, &gear), "sha256")
+	if _, err := EnsureConfig(path, other); !errors.Is(err, ErrConfigMismatch) {
+		t.Errorf("expected ErrConfigMismatch for gear table, got %v", err)
+	}
+
+	// Record separator changes boundaries → rejected
+	dump := fastcdc.NewParams(2<<10, 8<<10, 64<<10, nil)
+	dump.RecordSep = "\n"
+	if _, err := EnsureConfig(path, NewConfig(dump, "sha256")); !errors.Is(err, ErrConfigMismatch) {
+		t.Errorf("expected ErrConfigMismatch for record separator, got %v", err)
+	}
+
+	// Normalized chunking changes boundaries → rejected
+	normalized := fastcdc.NewParams(2<<10, 8<<10, 64<<10, nil).Normalized(2)
+	if _, err := EnsureConfig(path, NewConfig(normalized, "sha256")); !errors.Is(err, ErrConfigMismatch) {
+		t.Errorf("expected ErrConfigMismatch for normalization level, got %v", err)
+	}
+
+	// Custom mask changes boundaries → rejected
+	masked := fastcdc.NewParams(2<<10, 8<<10, 64<<10, nil)
+	masked.Mask = 0x3fff
+	if _, err := EnsureConfig(path, NewConfig(masked, "sha256")); !errors.Is(err, ErrConfigMismatch) {
+		t.Errorf("expected ErrConfigMismatch for mask, got %v", err)
+	}
+
+	// Different hash → rejected
+	other = NewConfig(fastcdc.NewParams(2<<10, 8<<10, 64<<10, nil), "sha1")
+	if _, err := EnsureConfig(path, other); !errors.Is(err, ErrConfigMismatch) {
+		t.Errorf("expected ErrConfigMismatch for hash, got %v", err)
+	}
+}
+
+// TestNewHasher_Unsupported verifies unknown hash names are rejected.
+func TestNewHasher_Unsupported(t *testing.T) {
+	if _, err := NewHasher("md4"); !errors.Is(err, ErrUnsupportedHash) {
+		t.Errorf("expected ErrUnsupportedHash, got %v", err)
+	}
+
+	want := NewConfig(fastcdc.NewParams(2<<10, 8<<10, 64<<10, nil), "md4")
+	if _, err := EnsureConfig(filepath.Join(t.TempDir(), "config.json"), want); !errors.Is(err, ErrUnsupportedHash) {
+		t.Errorf("expected ErrUnsupportedHash from EnsureConfig, got %v", err)
+	}
+}
+
+// TestLoadConfig_LegacyMask checks that configs saved before Mask was
+// recorded load with the default mask for their sizes.
+func TestLoadConfig_LegacyMask(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"Version":1,"MinSize":2048,"AvgSize":8192,"MaxSize":65536,"GearID":"default","Hash":"sha256"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := NewConfig(fastcdc.NewParams(2<<10, 8<<10, 64<<10, nil), "sha256")
+	if _, err := EnsureConfig(path, want); err != nil {
+		t.Errorf("legacy config rejected: %v", err)
+	}
+}
+
+// TestPersistentIndexJSON_Config checks that the config is created through
+// the index, reloaded by new instances, and enforced.
+func TestPersistentIndexJSON_Config(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	want := NewConfig(fastcdc.NewParams(2<<10, 8<<10, 64<<10, nil), "sha256")
+
+	idx, err := NewPersistentIndexJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := idx.Config(); ok {
+		t.Fatal("new index should have no config")
+	}
+	if _, err := idx.EnsureConfig(want); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewPersistentIndexJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := reopened.Config()
+	if !ok || got != want {
+		t.Fatalf("reopened config = %+v, %v", got, ok)
+	}
+
+	other := NewConfig(fastcdc.NewParams(4<<10, 16<<10, 64<<10, nil), "sha256")
+	if _, err := reopened.EnsureConfig(other); !errors.Is(err, ErrConfigMismatch) {
+		t.Errorf("expected ErrConfigMismatch, got %v", err)
+	}
+
+	if err := got.CheckChunk(types.Chunk{Size: 70 << 10, Hash: make([]byte, 32)}); !errors.Is(err, ErrConfigMismatch) {
+		t.Errorf("oversized chunk: err = %v", err)
+	}
+	if err := got.CheckChunk(types.Chunk{Size: 10, Hash: make([]byte, 20)}); !errors.Is(err, ErrConfigMismatch) {
+		t.Errorf("sha1-sized hash: err = %v", err)
+	}
+}
+
+// TestPersistentIndexJSON_ForeignConfig checks that an unrelated config.json
+// next to the index is ignored, and that an invalid repository config is
+// rejected with a clear error instead of breaking later writes.
+func TestPersistentIndexJSON_ForeignConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.json")
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"listen":":8080"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := NewPersistentIndexJSON(path)
+	if err != nil {
+		t.Fatalf("unrelated config.json broke open: %v", err)
+	}
+	if _, ok := idx.Config(); ok {
+		t.Error("unrelated config.json loaded as repository config")
+	}
+
+	for name, content := range map[string]string{
+		"no version":    `{"listen":":8080"}`,
+		"unknown hash":  `{"Version":1,"MinSize":2048,"AvgSize":8192,"MaxSize":65536,"Hash":"md5"}`,
+		"bad sizes":     `{"Version":1,"MinSize":0,"AvgSize":8192,"MaxSize":4096,"Hash":"sha256"}`,
+		"not an object": `[1, 2, 3]`,
+	} {
+		if err := os.WriteFile(ConfigPath(path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewPersistentIndexJSON(path); err == nil {
+			t.Errorf("%s: expected error for invalid repository config", name)
+		}
+	}
+}
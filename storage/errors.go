@@ -0,0 +1,15 @@
+package storage
+
+import "errors"
+
+// Sentinel errors returned (wrapped) by indices, writers, and storage.
+// Use errors.Is to test for them rather than matching error strings.
+var (
+	ErrChunkNotFound   = errors.New("chunk not found")            // no chunk with the requested hash
+	ErrCorruptChunk    = errors.New("corrupt chunk")              // chunk data does not match its hash
+	ErrIndexCorrupted  = errors.New("index corrupted")            // index file cannot be parsed
+	ErrUnsupportedHash = errors.New("unsupported hash algorithm") // hash algorithm not recognized
+	ErrConfigMismatch  = errors.New("repository config mismatch") // settings differ from the repository config
+	ErrAmbiguousPrefix = errors.New("ambiguous hash prefix")      // short hash matches several chunks
+	ErrUnknownBackend  = errors.New("unknown backend")            // no backend registered for a URI scheme
+)
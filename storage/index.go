@@ -2,7 +2,11 @@ package storage
 
 import (
 	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/AumSahayata/cdcgo/types"
 )
@@ -35,6 +39,40 @@ type PersistentIndex interface {
 	GetWithErr(hash string) (types.Chunk, bool, error) // Retrieve chunk metadata, with error reporting
 }
 
+// BatchIndex is implemented by indices that can answer many existence
+// checks at once, e.g. in a single round trip to a remote service.
+// Use the ExistsBatch function to query any Index, batched or not.
+type BatchIndex interface {
+	ExistsBatch(hashes []string) ([]bool, error) // result[i] reports whether hashes[i] exists
+}
+
+// ExistsBatch reports, for each hash, whether it exists in idx.
+//
+// If idx implements BatchIndex its ExistsBatch is used. Otherwise each hash
+// is checked individually, via ExistsWithErr when idx is a PersistentIndex
+// so that lookup errors are surfaced.
+func ExistsBatch(idx Index, hashes []string) ([]bool, error) {
+	if b, ok := idx.(BatchIndex); ok {
+		return b.ExistsBatch(hashes)
+	}
+
+	out := make([]bool, len(hashes))
+	p, persistent := idx.(PersistentIndex)
+	for i, h := range hashes {
+		if !persistent {
+			out[i] = idx.Exists(h)
+			continue
+		}
+
+		ok, err := p.ExistsWithErr(h)
+		if err != nil {
+			return nil, fmt.Errorf("check %s: %w", h, err)
+		}
+		out[i] = ok
+	}
+	return out, nil
+}
+
 // MemoryIndex is a simple in-memory implementation of Index.
 // It uses a sync.RWMutex to allow safe concurrent access.
 //
@@ -42,14 +80,19 @@ type PersistentIndex interface {
 // It should not be used in large-scale production environments
 // where durability or distributed access is required.
 type MemoryIndex struct {
-	store map[string]types.Chunk
-	mu    sync.RWMutex
+	store  map[string]types.Chunk
+	claims map[string]lease    // upload leases by hash
+	pins   map[string]struct{} // hashes protected from GC
+	cfg    *Config             // repository config, nil until EnsureConfig
+	mu     sync.RWMutex
 }
 
 // NewMemoryIndex creates an empty MemoryIndex.
 func NewMemoryIndex() *MemoryIndex {
 	return &MemoryIndex{
-		store: make(map[string]types.Chunk),
+		store:  make(map[string]types.Chunk),
+		claims: make(map[string]lease),
+		pins:   make(map[string]struct{}),
 	}
 }
 
@@ -72,6 +115,19 @@ func (m *MemoryIndex) Exists(hash string) bool {
 	return ok
 }
 
+// ExistsBatch reports, for each hash, whether it exists in the index.
+// It implements BatchIndex and never fails.
+func (m *MemoryIndex) ExistsBatch(hashes []string) ([]bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make([]bool, len(hashes))
+	for i, h := range hashes {
+		_, out[i] = m.store[h]
+	}
+	return out, nil
+}
+
 // Get retrieves a chunk by its hash.
 // Returns (chunk, true) if found, otherwise (zero, false).
 func (m *MemoryIndex) Get(hash string) (types.Chunk, bool) {
@@ -81,3 +137,102 @@ func (m *MemoryIndex) Get(hash string) (types.Chunk, bool) {
 	ch, ok := m.store[hash]
 	return ch, ok
 }
+
+// Config returns the config set by EnsureConfig, if any.
+// It implements ConfigIndex.
+func (m *MemoryIndex) Config() (Config, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.cfg == nil {
+		return Config{}, false
+	}
+	return *m.cfg, true
+}
+
+// EnsureConfig adopts want if no config is set yet, otherwise checks want
+// against it. Returns the config in effect.
+func (m *MemoryIndex) EnsureConfig(want Config) (Config, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cfg != nil {
+		return *m.cfg, m.cfg.Check(want)
+	}
+	if _, err := NewHasher(want.Hash); err != nil {
+		return Config{}, err
+	}
+	m.cfg = &want
+	return want, nil
+}
+
+// Resolve returns the single chunk whose hex hash starts with prefix.
+// It implements ResolveIndex.
+func (m *MemoryIndex) Resolve(prefix string) (types.Chunk, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return resolvePrefix(m.store, prefix)
+}
+
+// lease is an upload lease held by the owner of token until expiry.
+type lease struct {
+	token  string
+	expiry time.Time
+}
+
+// Claim takes the upload lease on hash for ttl if no unexpired lease is held.
+// It implements ClaimIndex for writers sharing one MemoryIndex.
+func (m *MemoryIndex) Claim(hash string, ttl time.Duration) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if l, ok := m.claims[hash]; ok && now.Before(l.expiry) {
+		return "", nil
+	}
+	token := newLeaseToken()
+	m.claims[hash] = lease{token: token, expiry: now.Add(ttl)}
+	return token, nil
+}
+
+// Release drops the upload lease on hash if token still owns it.
+func (m *MemoryIndex) Release(hash, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if l, ok := m.claims[hash]; ok && l.token == token {
+		delete(m.claims, hash)
+	}
+	return nil
+}
+
+// Pin protects the chunk with the given hash from garbage collection.
+// It implements PinIndex.
+func (m *MemoryIndex) Pin(hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.store[hash]; !ok {
+		return fmt.Errorf("pin %s: %w", hash, ErrChunkNotFound)
+	}
+	m.pins[hash] = struct{}{}
+	return nil
+}
+
+// Unpin removes the pin on hash.
+func (m *MemoryIndex) Unpin(hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.pins, hash)
+	return nil
+}
+
+// Pins returns all pinned hashes in sorted order.
+func (m *MemoryIndex) Pins() ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return slices.Sorted(maps.Keys(m.pins)), nil
+}
@@ -98,6 +98,34 @@ func TestMemoryIndex_Concurrent(t *testing.T) {
 	<-done
 }
 
+// plainIndex hides optional interfaces of the wrapped Index.
+type plainIndex struct{ Index }
+
+// TestExistsBatch verifies batched lookups, both through MemoryIndex's own
+// implementation and through the per-hash fallback.
+func TestExistsBatch(t *testing.T) {
+	a := helperChunk([]byte("a"), 1)
+	b := helperChunk([]byte("b"), 1)
+
+	mi := NewMemoryIndex()
+	_ = mi.Add(a)
+
+	hashes := []string{a.HexHash(), b.HexHash(), a.HexHash()}
+	want := []bool{true, false, true}
+
+	for name, idx := range map[string]Index{"batch": mi, "fallback": plainIndex{mi}} {
+		got, err := ExistsBatch(idx, hashes)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: result[%d] = %v, want %v", name, i, got[i], want[i])
+			}
+		}
+	}
+}
+
 // BenchmarkMemoryIndex_Add measures write throughput (Add only).
 func BenchmarkMemoryIndex_Add(b *testing.B) {
 	idx := NewMemoryIndex()
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package storage
+
+// haveFileLock reports whether lockFile locks across processes.
+const haveFileLock = false
+
+// lockFile is a no-op on platforms without flock; PersistentIndexJSON then
+// relies on merge-on-write alone for multi-process safety.
+func lockFile(path string) (unlock func(), err error) {
+	return func() {}, nil
+}
@@ -3,9 +3,17 @@ package storage
 import (
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"maps"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/AumSahayata/cdcgo/types"
 )
@@ -19,6 +27,11 @@ import (
 // Concurrency:
 //   - Safe for concurrent use via an internal RWMutex.
 //   - Each mutation is flushed to disk to ensure durability.
+//   - Several processes may share one path: writes take an exclusive lock
+//     on path+".lock" (flock on Unix), and if the file changed on disk since
+//     it was last read, its entries are merged with the in-memory ones
+//     before writing, so no process's entries are lost. On platforms
+//     without file locking only the merge step applies.
 //
 // Notes:
 //   - Best for small/medium datasets.
@@ -27,44 +40,121 @@ type PersistentIndexJSON struct {
 	path  string                 // file path on disk
 	store map[string]types.Chunk // in-memory representation
 	mu    sync.RWMutex           // concurrency control
+	log   *slog.Logger           // structured logger, silent by default
+	seen  fileStamp              // on-disk state last read or written
+	cfg   *Config                // repository config, nil if none
+}
+
+// fileStamp identifies a version of the index file on disk.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// stampOf returns the fileStamp of fi.
+func stampOf(fi os.FileInfo) fileStamp {
+	return fileStamp{modTime: fi.ModTime(), size: fi.Size()}
 }
 
 // NewPersistentIndexJSON creates (or loads) a JSON-backed persistent index.
 //
 // If the file already exists, it will be loaded into memory.
-// If not, an empty index will be created.
+// If not, an empty index will be created. The repository config in
+// cdcgo-config.json next to the index (see ConfigPath) is loaded too if
+// present, and writers check chunks against it.
 //
 // Parameters:
 //   - path: file path to the JSON index file
 //
 // Returns:
 //   - *PersistentIndexJSON instance
-//   - error if the file cannot be read or parsed
+//   - error if the file cannot be read or parsed, or the config is invalid
 func NewPersistentIndexJSON(path string) (*PersistentIndexJSON, error) {
 	idx := &PersistentIndexJSON{
 		path:  path,
 		store: make(map[string]types.Chunk),
+		log:   slog.New(slog.DiscardHandler),
 	}
 
 	// Check if the file exists
-	if _, err := os.Stat(path); err == nil {
+	if fi, err := os.Stat(path); err == nil {
 		// File exists → load it
 		data, err := os.ReadFile(path)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("read index %s: %w", path, err)
 		}
 
 		// Unmarshal JSON into the store map
 		if err := json.Unmarshal(data, &idx.store); err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %s: %w", ErrIndexCorrupted, path, err)
 		}
+		idx.seen = stampOf(fi)
 	} else if !os.IsNotExist(err) {
 		// Other error besides "file not found"
+		return nil, fmt.Errorf("stat index %s: %w", path, err)
+	}
+
+	cfg, err := LoadConfig(ConfigPath(path))
+	if err == nil {
+		idx.cfg = &cfg
+	} else if !errors.Is(err, os.ErrNotExist) {
 		return nil, err
 	}
 	return idx, nil
 }
 
+// ConfigPath returns where the repository config for the index at
+// indexPath is stored: cdcgo-config.json in the same directory.
+func ConfigPath(indexPath string) string {
+	return filepath.Join(filepath.Dir(indexPath), "cdcgo-config.json")
+}
+
+// Config returns the repository config, if one exists.
+// It implements ConfigIndex.
+func (p *PersistentIndexJSON) Config() (Config, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.cfg == nil {
+		return Config{}, false
+	}
+	return *p.cfg, true
+}
+
+// EnsureConfig creates the repository config at ConfigPath from want if
+// none exists yet, otherwise checks want against it (see the EnsureConfig
+// function). Returns the config in effect.
+func (p *PersistentIndexJSON) EnsureConfig(want Config) (Config, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	unlock, err := lockFile(p.path + ".lock")
+	if err != nil {
+		return Config{}, fmt.Errorf("lock index %s: %w", p.path, err)
+	}
+	defer unlock()
+
+	cfg, err := EnsureConfig(ConfigPath(p.path), want)
+	if err != nil {
+		return cfg, err
+	}
+	p.cfg = &cfg
+	return cfg, nil
+}
+
+// SetLogger sets the logger used for debug events (entries added, reloads)
+// and errors (failed flushes, corrupted index files).
+// Passing nil silences logging again.
+func (p *PersistentIndexJSON) SetLogger(l *slog.Logger) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if l == nil {
+		l = slog.New(slog.DiscardHandler)
+	}
+	p.log = l
+}
+
 // Add inserts a chunk into the index and persists the update to disk.
 //
 // If the chunk already exists, it is silently ignored.
@@ -73,14 +163,51 @@ func (p *PersistentIndexJSON) Add(ch types.Chunk) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	key := hex.EncodeToString(ch.Hash)
+
+	unlock, err := lockFile(p.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("lock index %s: %w", p.path, err)
+	}
+	defer unlock()
+
 	newStore := make(map[string]types.Chunk)
+
+	// Another process may have written since we last looked: merge its entries
+	changed, err := p.changedOnDisk()
+	if err != nil {
+		return err
+	}
+	if changed {
+		disk, err := p.readStore()
+		if err != nil {
+			return err
+		}
+		maps.Copy(newStore, disk)
+		p.log.Debug("index merged with concurrent writer", "path", p.path, "entries", len(disk))
+	}
+
 	maps.Copy(newStore, p.store)
-	newStore[hex.EncodeToString(ch.Hash)] = ch
+	newStore[key] = ch
 
+	if err := p.flush(newStore); err != nil {
+		p.log.Error("index flush failed", "path", p.path, "hash", key, "err", err)
+		return fmt.Errorf("flush index %s: %w", p.path, err)
+	}
+
+	// Commit to memory.
+	p.store = newStore
+	p.log.Debug("index entry added", "path", p.path, "hash", key, "size", ch.Size)
+
+	return nil
+}
+
+// flush atomically writes store to disk via a temp file and rename.
+func (p *PersistentIndexJSON) flush(store map[string]types.Chunk) error {
 	// Serialize to JSON
-	data, err := json.MarshalIndent(newStore, "", " ")
+	data, err := json.MarshalIndent(store, "", " ")
 	if err != nil {
-		return err
+		return fmt.Errorf("encode index: %w", err)
 	}
 
 	// Write to temp file.
@@ -108,12 +235,26 @@ func (p *PersistentIndexJSON) Add(ch types.Chunk) error {
 		return err
 	}
 
-	// Commit to memory.
-	p.store = newStore
-
+	// Remember our own write so it is not mistaken for a concurrent one
+	if fi, err := os.Stat(p.path); err == nil {
+		p.seen = stampOf(fi)
+	}
 	return nil
 }
 
+// changedOnDisk reports whether the index file differs from the version
+// last read or written by this instance.
+func (p *PersistentIndexJSON) changedOnDisk() (bool, error) {
+	fi, err := os.Stat(p.path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("stat index %s: %w", p.path, err)
+	}
+	return stampOf(fi) != p.seen, nil
+}
+
 // Exists checks if a chunk with the given hash exists in the index.
 //
 // Returns:
@@ -142,17 +283,49 @@ func (p *PersistentIndexJSON) ExistsWithErr(hash string) (bool, error) {
 
 	// Reload from JSON file
 	if err := p.load(); err != nil {
-        if os.IsNotExist(err) {
-            // File does not exist → treat as empty store
-            return false, nil
-        }
-        return false, err // real I/O or parsing error
-    }
+		if errors.Is(err, os.ErrNotExist) {
+			// File does not exist → treat as empty store
+			return false, nil
+		}
+		return false, err // real I/O or parsing error
+	}
 
 	_, ok := p.store[hash]
 	return ok, nil
 }
 
+// ExistsBatch reports, for each hash, whether it exists in the index.
+// It implements BatchIndex: the file is reloaded at most once for the whole
+// batch, and only if some hash is missing from memory.
+func (p *PersistentIndexJSON) ExistsBatch(hashes []string) ([]bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]bool, len(hashes))
+	missing := false
+	for i, h := range hashes {
+		_, out[i] = p.store[h]
+		missing = missing || !out[i]
+	}
+	if !missing {
+		return out, nil
+	}
+
+	// Reload from JSON file
+	if err := p.load(); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// File does not exist → treat as empty store
+			return out, nil
+		}
+		return nil, err // real I/O or parsing error
+	}
+
+	for i, h := range hashes {
+		_, out[i] = p.store[h]
+	}
+	return out, nil
+}
+
 // Get retrieves a chunk by hash if available.
 //
 // Returns:
@@ -172,8 +345,8 @@ func (p *PersistentIndexJSON) Get(hash string) (types.Chunk, bool) {
 //   - boolean
 //   - error
 func (p *PersistentIndexJSON) GetWithErr(hash string) (types.Chunk, bool, error) {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
+	p.mu.Lock() // a miss reloads the store
+	defer p.mu.Unlock()
 
 	// Check in-memory
 	if ch, ok := p.store[hash]; ok {
@@ -182,12 +355,12 @@ func (p *PersistentIndexJSON) GetWithErr(hash string) (types.Chunk, bool, error)
 
 	// Reload from JSON file
 	if err := p.load(); err != nil {
-        if os.IsNotExist(err) {
-            // File does not exist → treat as empty store
+		if errors.Is(err, os.ErrNotExist) {
+			// File does not exist → treat as empty store
 			return types.Chunk{}, false, nil
-        }
-        return types.Chunk{}, false, err // real I/O or parsing error
-    }
+		}
+		return types.Chunk{}, false, err // real I/O or parsing error
+	}
 
 	ch, ok := p.store[hash]
 	if !ok {
@@ -197,21 +370,223 @@ func (p *PersistentIndexJSON) GetWithErr(hash string) (types.Chunk, bool, error)
 	return ch, true, nil
 }
 
+// Resolve returns the single chunk whose hex hash starts with prefix.
+// It implements ResolveIndex. The file is reloaded first if another
+// process has changed it, so ambiguity is judged against all entries.
+func (p *PersistentIndexJSON) Resolve(prefix string) (types.Chunk, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed, err := p.changedOnDisk()
+	if err != nil {
+		return types.Chunk{}, err
+	}
+	if changed {
+		if err := p.load(); err != nil {
+			return types.Chunk{}, err
+		}
+	}
+
+	return resolvePrefix(p.store, prefix)
+}
+
 // load loads the JSON file into the in-memory map.
 //
 // Called at initialization, and can be used to refresh state.
 func (p *PersistentIndexJSON) load() error {
-	data, err := os.ReadFile(p.path)
+	tmp, err := p.readStore()
 	if err != nil {
 		return err
 	}
 
+	// Replace in-memory store with fresh state
+	p.store = tmp
+	p.log.Debug("index reloaded", "path", p.path, "entries", len(tmp))
+	return nil
+}
+
+// readStore reads and parses the JSON file and records its fileStamp.
+func (p *PersistentIndexJSON) readStore() (map[string]types.Chunk, error) {
+	fi, err := os.Stat(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("stat index %s: %w", p.path, err)
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("read index %s: %w", p.path, err)
+	}
+
 	tmp := make(map[string]types.Chunk)
 	if err := json.Unmarshal(data, &tmp); err != nil {
+		p.log.Error("index file corrupted", "path", p.path, "err", err)
+		return nil, fmt.Errorf("%w: %s: %w", ErrIndexCorrupted, p.path, err)
+	}
+
+	p.seen = stampOf(fi)
+	return tmp, nil
+}
+
+// Claim takes the upload lease on hash for ttl if no unexpired lease is held.
+// It implements ClaimIndex across processes sharing the index path: leases
+// are files under path+".claims" holding their expiry time and owner token,
+// created and checked under the same locks as Add. Where file locking is
+// unavailable (see lockFile), leases are only exclusive within one process.
+func (p *PersistentIndexJSON) Claim(hash string, ttl time.Duration) (string, error) {
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", fmt.Errorf("claim %q: invalid hash: %w", hash, err)
+	}
+
+	// p.mu serializes goroutines sharing this instance, including on
+	// platforms where lockFile cannot lock across processes
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	unlock, err := lockFile(p.path + ".lock")
+	if err != nil {
+		return "", fmt.Errorf("lock index %s: %w", p.path, err)
+	}
+	defer unlock()
+
+	dir := p.path + ".claims"
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("claim %s: %w", hash, err)
+	}
+	claimPath := filepath.Join(dir, hash)
+
+	now := time.Now()
+	if data, err := os.ReadFile(claimPath); err == nil {
+		exp, _, perr := parseLease(data)
+		if perr == nil && now.UnixNano() < exp {
+			return "", nil // held by someone else
+		}
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("claim %s: %w", hash, err)
+	}
+
+	token := newLeaseToken()
+	data := strconv.FormatInt(now.Add(ttl).UnixNano(), 10) + " " + token
+	if err := os.WriteFile(claimPath, []byte(data), 0644); err != nil {
+		return "", fmt.Errorf("claim %s: %w", hash, err)
+	}
+	p.log.Debug("chunk claimed", "path", p.path, "hash", hash, "ttl", ttl)
+	return token, nil
+}
+
+// parseLease parses a lease file: the expiry in Unix nanoseconds and the
+// owner token, separated by a space.
+func parseLease(data []byte) (expiry int64, token string, err error) {
+	exp, token, _ := strings.Cut(string(data), " ")
+	expiry, err = strconv.ParseInt(exp, 10, 64)
+	return expiry, token, err
+}
+
+// Release drops the upload lease on hash if token still owns it.
+func (p *PersistentIndexJSON) Release(hash, token string) error {
+	if _, err := hex.DecodeString(hash); err != nil {
+		return fmt.Errorf("release %q: invalid hash: %w", hash, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	unlock, err := lockFile(p.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("lock index %s: %w", p.path, err)
+	}
+	defer unlock()
+
+	claimPath := filepath.Join(p.path+".claims", hash)
+	data, err := os.ReadFile(claimPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("release %s: %w", hash, err)
+	}
+	if _, owner, err := parseLease(data); err != nil || owner != token {
+		return nil // taken over by another writer after expiry
+	}
+
+	if err := os.Remove(claimPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("release %s: %w", hash, err)
+	}
+	return nil
+}
+
+// Pin protects the chunk with the given hash from garbage collection.
+// It implements PinIndex across processes sharing the index path: pins are
+// empty files under path+".pins", created under the same file lock as Add.
+func (p *PersistentIndexJSON) Pin(hash string) error {
+	if _, err := hex.DecodeString(hash); err != nil {
+		return fmt.Errorf("pin %q: invalid hash: %w", hash, err)
+	}
+
+	ok, err := p.ExistsWithErr(hash)
+	if err != nil {
 		return err
 	}
+	if !ok {
+		return fmt.Errorf("pin %s: %w", hash, ErrChunkNotFound)
+	}
 
-	// Replace in-memory store with fresh state
-	p.store = tmp
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	unlock, err := lockFile(p.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("lock index %s: %w", p.path, err)
+	}
+	defer unlock()
+
+	dir := p.path + ".pins"
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("pin %s: %w", hash, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, hash), nil, 0644); err != nil {
+		return fmt.Errorf("pin %s: %w", hash, err)
+	}
+	p.log.Debug("chunk pinned", "path", p.path, "hash", hash)
+	return nil
+}
+
+// Unpin removes the pin on hash.
+func (p *PersistentIndexJSON) Unpin(hash string) error {
+	if _, err := hex.DecodeString(hash); err != nil {
+		return fmt.Errorf("unpin %q: invalid hash: %w", hash, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	unlock, err := lockFile(p.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("lock index %s: %w", p.path, err)
+	}
+	defer unlock()
+
+	err = os.Remove(filepath.Join(p.path+".pins", hash))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unpin %s: %w", hash, err)
+	}
+	p.log.Debug("chunk unpinned", "path", p.path, "hash", hash)
 	return nil
 }
+
+// Pins returns all pinned hashes in sorted order.
+func (p *PersistentIndexJSON) Pins() ([]string, error) {
+	entries, err := os.ReadDir(p.path + ".pins")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list pins of %s: %w", p.path, err)
+	}
+
+	pins := make([]string, 0, len(entries))
+	for _, e := range entries {
+		pins = append(pins, e.Name())
+	}
+	slices.Sort(pins)
+	return pins, nil
+}
@@ -1,9 +1,17 @@
 package storage
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
+
+	"github.com/AumSahayata/cdcgo/types"
 )
 
 // TestPersistentIndexJSON_AddAndExists verifies chunks can be added
@@ -66,6 +74,41 @@ func TestPersistentIndexJSON_Get(t *testing.T) {
 	}
 }
 
+// TestPersistentIndexJSON_StorageMetadata verifies that compression and
+// encryption metadata round-trips through the JSON file.
+func TestPersistentIndexJSON_StorageMetadata(t *testing.T) {
+	path := t.TempDir() + "/index.json"
+	idx, err := NewPersistentIndexJSON(path)
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+
+	ch := helperChunk([]byte("squeezed"), 8)
+	ch.CompressedSize = 5
+	ch.Codec = "zstd"
+	ch.Nonce = []byte{1, 2, 3, 4}
+	ch.Flags = types.FlagCompressed | types.FlagEncrypted
+	if err := idx.Add(ch); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+
+	idx2, err := NewPersistentIndexJSON(path)
+	if err != nil {
+		t.Fatalf("failed to reopen index: %v", err)
+	}
+
+	got, ok := idx2.Get(ch.HexHash())
+	if !ok {
+		t.Fatalf("expected chunk to exist after reload")
+	}
+	if got.CompressedSize != 5 || got.Codec != "zstd" || !bytes.Equal(got.Nonce, ch.Nonce) {
+		t.Errorf("metadata mismatch: got %+v", got)
+	}
+	if !got.Flags.Has(types.FlagCompressed|types.FlagEncrypted) || got.StoredSize() != 5 {
+		t.Errorf("flags or stored size mismatch: flags=%b stored=%d", got.Flags, got.StoredSize())
+	}
+}
+
 // TestPersistentIndexJSON_NonExistent ensures ExistsWithErr() and GetWithErr() behave correctly
 // when the chunk is not in the index.
 func TestPersistentIndexJSON_NonExistent(t *testing.T) {
@@ -128,6 +171,77 @@ func TestPersistentIndexJSON_Concurrent(t *testing.T) {
 	<-done
 }
 
+// TestPersistentIndexJSON_MultiWriter verifies that two instances sharing
+// one path (as two processes would) do not lose each other's entries.
+func TestPersistentIndexJSON_MultiWriter(t *testing.T) {
+	path := t.TempDir() + "/index.json"
+
+	idx1, err := NewPersistentIndexJSON(path)
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+	idx2, err := NewPersistentIndexJSON(path)
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for w, idx := range []*PersistentIndexJSON{idx1, idx2} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				ch := helperChunk([]byte(fmt.Sprintf("writer-%d-chunk-%d", w, i)), 16)
+				if err := idx.Add(ch); err != nil {
+					t.Errorf("add failed: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	reopened, err := NewPersistentIndexJSON(path)
+	if err != nil {
+		t.Fatalf("failed to reopen index: %v", err)
+	}
+	for w := range 2 {
+		for i := range 50 {
+			ch := helperChunk([]byte(fmt.Sprintf("writer-%d-chunk-%d", w, i)), 16)
+			if !reopened.Exists(ch.HexHash()) {
+				t.Fatalf("entry %d of writer %d lost", i, w)
+			}
+		}
+	}
+}
+
+// TestPersistentIndexJSON_ExistsBatch verifies that a batch sees entries
+// written by another instance.
+func TestPersistentIndexJSON_ExistsBatch(t *testing.T) {
+	path := t.TempDir() + "/index.json"
+
+	reader, err := NewPersistentIndexJSON(path)
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+	writer, err := NewPersistentIndexJSON(path)
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+
+	ch := helperChunk([]byte("batched"), 7)
+	if err := writer.Add(ch); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+
+	got, err := reader.ExistsBatch([]string{ch.HexHash(), "unknown"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got[0] || got[1] {
+		t.Errorf("ExistsBatch = %v, want [true false]", got)
+	}
+}
+
 // TestPersistentIndexJSON_CorruptedFile verifies that
 // ExistsWithErr and GetWithErr return errors if the JSON file is corrupted.
 func TestPersistentIndexJSON_CorruptedFile(t *testing.T) {
@@ -144,6 +258,36 @@ func TestPersistentIndexJSON_CorruptedFile(t *testing.T) {
 	if err == nil {
 		t.Fatalf("expected error due to corrupted file, got nil")
 	}
+	if !errors.Is(err, ErrIndexCorrupted) {
+		t.Errorf("expected ErrIndexCorrupted, got %v", err)
+	}
+}
+
+// TestPersistentIndexJSON_Logger verifies that additions and reloads are
+// reported as structured debug events.
+func TestPersistentIndexJSON_Logger(t *testing.T) {
+	path := t.TempDir() + "/index.json"
+	idx, err := NewPersistentIndexJSON(path)
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+
+	var logs bytes.Buffer
+	idx.SetLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	ch := helperChunk([]byte("logged"), 6)
+	if err := idx.Add(ch); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+	_, _ = idx.ExistsWithErr("missing")
+
+	out := logs.String()
+	if !strings.Contains(out, `msg="index entry added"`) || !strings.Contains(out, "hash="+ch.HexHash()) {
+		t.Errorf("missing entry added event in logs:\n%s", out)
+	}
+	if !strings.Contains(out, `msg="index reloaded"`) {
+		t.Errorf("missing reload event in logs:\n%s", out)
+	}
 }
 
 // BenchmarkPersistentIndexJSON_Add measures write throughput (Add only).
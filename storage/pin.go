@@ -0,0 +1,14 @@
+package storage
+
+// PinIndex is implemented by indices that can pin chunks.
+//
+// A pinned chunk must never be garbage collected, whether or not any
+// manifest still references it (e.g. bootstrap images). Pinning a hash that
+// is not indexed fails with ErrChunkNotFound; pinning twice and unpinning a
+// hash that is not pinned are no-ops.
+type PinIndex interface {
+	Index
+	Pin(hash string) error   // protect the chunk from garbage collection
+	Unpin(hash string) error // remove the protection
+	Pins() ([]string, error) // all pinned hashes, sorted
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// TestPin checks pinning, enumeration, and unpinning on both indices, and
+// that JSON pins are visible to another instance sharing the file.
+func TestPin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	jsonIdx, err := NewPersistentIndexJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, idx := range map[string]PinIndex{"memory": NewMemoryIndex(), "json": jsonIdx} {
+		t.Run(name, func(t *testing.T) {
+			a := types.Chunk{Hash: []byte{0x0a}, Size: 1}
+			b := types.Chunk{Hash: []byte{0x0b}, Size: 1}
+			_ = idx.Add(a)
+			_ = idx.Add(b)
+
+			if err := idx.Pin("0c"); !errors.Is(err, ErrChunkNotFound) {
+				t.Errorf("pin of unknown chunk: err = %v, want ErrChunkNotFound", err)
+			}
+			for _, h := range []string{"0b", "0a", "0a"} {
+				if err := idx.Pin(h); err != nil {
+					t.Fatalf("pin %s: %v", h, err)
+				}
+			}
+
+			pins, err := idx.Pins()
+			if err != nil || !slices.Equal(pins, []string{"0a", "0b"}) {
+				t.Fatalf("Pins() = %v, %v", pins, err)
+			}
+
+			if err := idx.Unpin("0a"); err != nil {
+				t.Fatal(err)
+			}
+			if err := idx.Unpin("0a"); err != nil {
+				t.Errorf("second unpin: %v", err)
+			}
+			if pins, _ := idx.Pins(); !slices.Equal(pins, []string{"0b"}) {
+				t.Errorf("after unpin Pins() = %v", pins)
+			}
+		})
+	}
+
+	other, err := NewPersistentIndexJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pins, _ := other.Pins(); !slices.Equal(pins, []string{"0b"}) {
+		t.Errorf("pins seen by second instance = %v", pins)
+	}
+	if err := other.Pin("../x"); err == nil {
+		t.Error("expected error for invalid hash")
+	}
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"sync"
+)
+
+// IndexFactory opens an index from a parsed URI. Query parameters carry
+// backend-specific options.
+type IndexFactory func(u *url.URL) (Index, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]IndexFactory{
+		"memory": func(*url.URL) (Index, error) { return NewMemoryIndex(), nil },
+		"json": func(u *url.URL) (Index, error) {
+			if u.Host != "" {
+				// json://dir/index.json would silently drop "dir"
+				return nil, fmt.Errorf("json index: unexpected host %q in %q; use json:relative/path or json:///absolute/path", u.Host, u.String())
+			}
+			path := u.Path
+			if path == "" {
+				path = u.Opaque // json:relative/index.json
+			}
+			if path == "" {
+				return nil, fmt.Errorf("json index: missing path in %q", u.String())
+			}
+			return NewPersistentIndexJSON(path)
+		},
+	}
+)
+
+// RegisterIndex registers (or replaces) the factory for indices with the
+// given URI scheme, so applications can select backends from configuration
+// strings. "memory" and "json" are built in; other backends register
+// themselves, typically from an init function:
+//
+//	func init() {
+//		storage.RegisterIndex("bolt", openBoltIndex)
+//	}
+func RegisterIndex(scheme string, f IndexFactory) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	backends[scheme] = f
+}
+
+// OpenIndex opens the index described by uri, e.g. "memory:",
+// "json:///var/lib/backup/index.json", or "json:relative/index.json".
+// It returns an error wrapping
+// ErrUnknownBackend if no factory is registered for the scheme.
+func OpenIndex(uri string) (Index, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("open index %q: %w", uri, err)
+	}
+
+	backendsMu.RLock()
+	f, ok := backends[u.Scheme]
+	backendsMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("open index %q: %w: %q", uri, ErrUnknownBackend, u.Scheme)
+	}
+	return f(u)
+}
+
+// IndexSchemes returns the registered URI schemes in sorted order.
+func IndexSchemes() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	return slices.Sorted(maps.Keys(backends))
+}
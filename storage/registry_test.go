@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"errors"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestOpenIndex checks the built-in schemes, registration, and unknown
+// schemes.
+func TestOpenIndex(t *testing.T) {
+	if idx, err := OpenIndex("memory:"); err != nil {
+		t.Errorf("memory: %v", err)
+	} else if _, ok := idx.(*MemoryIndex); !ok {
+		t.Errorf("memory: got %T", idx)
+	}
+
+	path := filepath.ToSlash(filepath.Join(t.TempDir(), "index.json"))
+	if idx, err := OpenIndex("json://" + path); err != nil {
+		t.Errorf("json: %v", err)
+	} else if _, ok := idx.(*PersistentIndexJSON); !ok {
+		t.Errorf("json: got %T", idx)
+	}
+	if _, err := OpenIndex("json:"); err == nil {
+		t.Error("expected error for json index without path")
+	}
+	if _, err := OpenIndex("json://relative/index.json"); err == nil {
+		t.Error("expected error for json index with a host")
+	}
+
+	t.Chdir(t.TempDir())
+	if idx, err := OpenIndex("json:relative.json"); err != nil {
+		t.Errorf("json relative: %v", err)
+	} else if got := idx.(*PersistentIndexJSON).path; got != "relative.json" {
+		t.Errorf("json relative: path %q", got)
+	}
+
+	if _, err := OpenIndex("s3://bucket/prefix"); !errors.Is(err, ErrUnknownBackend) {
+		t.Errorf("err = %v, want ErrUnknownBackend", err)
+	}
+
+	var gotRegion string
+	RegisterIndex("test", func(u *url.URL) (Index, error) {
+		gotRegion = u.Query().Get("region")
+		return NewMemoryIndex(), nil
+	})
+	defer func() {
+		backendsMu.Lock()
+		delete(backends, "test")
+		backendsMu.Unlock()
+	}()
+
+	if _, err := OpenIndex("test://bucket?region=eu-west-1"); err != nil || gotRegion != "eu-west-1" {
+		t.Errorf("registered backend: err %v, region %q", err, gotRegion)
+	}
+	if !slices.Contains(IndexSchemes(), "test") {
+		t.Errorf("IndexSchemes() = %v, missing test", IndexSchemes())
+	}
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// ResolveIndex is implemented by indices that can look up chunks by a
+// short hash prefix, so CLI and debugging tools can refer to chunks the way
+// git refers to commits.
+type ResolveIndex interface {
+	Index
+	Resolve(prefix string) (types.Chunk, error) // the single chunk whose hex hash starts with prefix
+}
+
+// resolvePrefix finds the single entry of store whose key starts with
+// prefix. It returns an error wrapping ErrChunkNotFound if none matches and
+// ErrAmbiguousPrefix if several do.
+func resolvePrefix(store map[string]types.Chunk, prefix string) (types.Chunk, error) {
+	prefix = strings.ToLower(prefix)
+	if prefix == "" {
+		return types.Chunk{}, fmt.Errorf("resolve: empty prefix: %w", ErrAmbiguousPrefix)
+	}
+
+	// Full hashes need no scan
+	if ch, ok := store[prefix]; ok {
+		return ch, nil
+	}
+
+	var found types.Chunk
+	matches := 0
+	for key, ch := range store {
+		if strings.HasPrefix(key, prefix) {
+			found = ch
+			matches++
+		}
+	}
+
+	switch matches {
+	case 0:
+		return types.Chunk{}, fmt.Errorf("resolve %s: %w", prefix, ErrChunkNotFound)
+	case 1:
+		return found, nil
+	}
+	return types.Chunk{}, fmt.Errorf("resolve %s: %d matches: %w", prefix, matches, ErrAmbiguousPrefix)
+}
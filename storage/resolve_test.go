@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// TestResolve checks unique, ambiguous, missing, and full-hash lookups on
+// both indices.
+func TestResolve(t *testing.T) {
+	jsonIdx, err := NewPersistentIndexJSON(filepath.Join(t.TempDir(), "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, idx := range map[string]ResolveIndex{"memory": NewMemoryIndex(), "json": jsonIdx} {
+		t.Run(name, func(t *testing.T) {
+			for _, h := range [][]byte{{0xab, 0xcd, 0x01}, {0xab, 0xce, 0x02}, {0x12, 0x34, 0x56}} {
+				_ = idx.Add(types.Chunk{Hash: h, Size: 1})
+			}
+
+			tests := []struct {
+				prefix string
+				want   []byte
+				err    error
+			}{
+				{"abcd", []byte{0xab, 0xcd, 0x01}, nil},
+				{"ABCE", []byte{0xab, 0xce, 0x02}, nil},
+				{"123456", []byte{0x12, 0x34, 0x56}, nil},
+				{"abc", nil, ErrAmbiguousPrefix},
+				{"", nil, ErrAmbiguousPrefix},
+				{"ff", nil, ErrChunkNotFound},
+			}
+			for _, tt := range tests {
+				ch, err := idx.Resolve(tt.prefix)
+				if !errors.Is(err, tt.err) || (tt.err == nil && !bytes.Equal(ch.Hash, tt.want)) {
+					t.Errorf("Resolve(%q) = %x, %v; want %x, %v", tt.prefix, ch.Hash, err, tt.want, tt.err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token-bucket limiter measured in bytes per second.
+//
+// A single RateLimiter can be shared by several readers or writers to cap
+// their combined throughput. Use one limiter for uploads and another for
+// downloads to enforce separate up/down limits.
+//
+// Concurrency:
+//   - Safe for concurrent use.
+//   - SetRate may be called at any time to adjust the limit at runtime.
+type RateLimiter struct {
+	rate   float64   // bytes per second; <= 0 means unlimited
+	burst  float64   // bucket capacity in bytes
+	tokens float64   // currently available bytes
+	last   time.Time // last refill time
+	mu     sync.Mutex
+}
+
+// NewRateLimiter creates a RateLimiter allowing bytesPerSec bytes per second
+// with bursts of up to burst bytes. A bytesPerSec <= 0 disables limiting.
+// If burst <= 0, it defaults to one second worth of bytes.
+func NewRateLimiter(bytesPerSec, burst int) *RateLimiter {
+	l := &RateLimiter{last: time.Now()}
+	l.setRate(bytesPerSec, burst)
+	l.tokens = l.burst
+	return l
+}
+
+// SetRate changes the limit at runtime. Waiting callers pick up the new
+// rate on their next refill.
+func (l *RateLimiter) SetRate(bytesPerSec, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	l.setRate(bytesPerSec, burst)
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// Rate returns the current limit in bytes per second (0 if unlimited).
+func (l *RateLimiter) Rate() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return int(l.rate)
+}
+
+// WaitN blocks until n bytes may be transferred.
+//
+// Requests larger than the burst size are admitted in burst-sized steps,
+// so a single large chunk never deadlocks the limiter.
+func (l *RateLimiter) WaitN(n int) {
+	remaining := float64(n)
+	for remaining > 0 {
+		l.mu.Lock()
+		if l.rate <= 0 {
+			l.mu.Unlock()
+			return
+		}
+
+		now := time.Now()
+		l.refill(now)
+
+		step := min(remaining, l.burst)
+		if l.tokens >= step {
+			l.tokens -= step
+			remaining -= step
+			l.mu.Unlock()
+			continue
+		}
+
+		wait := time.Duration((step - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
+
+// setRate updates rate and burst. Callers must hold l.mu.
+func (l *RateLimiter) setRate(bytesPerSec, burst int) {
+	l.rate = float64(bytesPerSec)
+	if burst <= 0 {
+		burst = bytesPerSec
+	}
+	l.burst = float64(burst)
+}
+
+// refill adds tokens accumulated since the last refill. Callers must hold l.mu.
+func (l *RateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+	l.tokens = min(l.burst, l.tokens+elapsed*l.rate)
+}
+
+// ThrottledWriter is an io.Writer that paces writes through a RateLimiter.
+//
+// Wrap the destination of a ChunkWriter (or any upload stream) with it to
+// cap upload bandwidth.
+type ThrottledWriter struct {
+	w   io.Writer
+	lim *RateLimiter
+}
+
+// NewThrottledWriter wraps w so that writes are limited by lim.
+func NewThrottledWriter(w io.Writer, lim *RateLimiter) *ThrottledWriter {
+	return &ThrottledWriter{w: w, lim: lim}
+}
+
+// Write waits for len(p) bytes of budget and then writes p.
+func (t *ThrottledWriter) Write(p []byte) (int, error) {
+	t.lim.WaitN(len(p))
+	return t.w.Write(p)
+}
+
+// Flush flushes the underlying writer if supported.
+func (t *ThrottledWriter) Flush() error {
+	if f, ok := t.w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+
+	return nil
+}
+
+// ThrottledReader is an io.Reader that paces reads through a RateLimiter.
+//
+// Wrap download streams (or the source of a ChunkReader) with it to cap
+// download bandwidth.
+type ThrottledReader struct {
+	r   io.Reader
+	lim *RateLimiter
+}
+
+// NewThrottledReader wraps r so that reads are limited by lim.
+func NewThrottledReader(r io.Reader, lim *RateLimiter) *ThrottledReader {
+	return &ThrottledReader{r: r, lim: lim}
+}
+
+// Read reads into p and then waits for budget covering the bytes read.
+func (t *ThrottledReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if n > 0 {
+		t.lim.WaitN(n)
+	}
+	return n, err
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+// TestThrottledWriter_Limits verifies that writes are paced by the limiter.
+func TestThrottledWriter_Limits(t *testing.T) {
+	// 10 KB/s with a 1 KB burst: 3 KB should take at least ~200ms
+	lim := NewRateLimiter(10<<10, 1<<10)
+	buf := &bytes.Buffer{}
+	w := NewThrottledWriter(buf, lim)
+
+	start := time.Now()
+	if _, err := w.Write(make([]byte, 3<<10)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if elapsed < 150*time.Millisecond {
+		t.Errorf("write finished too fast: %v", elapsed)
+	}
+	if buf.Len() != 3<<10 {
+		t.Errorf("bytes written = %d, want %d", buf.Len(), 3<<10)
+	}
+}
+
+// TestThrottledReader_Unlimited verifies that a zero rate disables limiting
+// and that the limit can be changed at runtime.
+func TestThrottledReader_Unlimited(t *testing.T) {
+	lim := NewRateLimiter(0, 0)
+	r := NewThrottledReader(bytes.NewReader(make([]byte, 1<<20)), lim)
+
+	start := time.Now()
+	n, err := io.Copy(io.Discard, r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 1<<20 {
+		t.Errorf("bytes read = %d, want %d", n, 1<<20)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("unlimited read was throttled")
+	}
+
+	lim.SetRate(1<<20, 0)
+	if got := lim.Rate(); got != 1<<20 {
+		t.Errorf("rate = %d, want %d", got, 1<<20)
+	}
+}
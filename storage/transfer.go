@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// TransferPlan lists the chunks that must be sent to bring a destination up
+// to date with a source chunk list. It is JSON-serializable so schedulers
+// can review and approve large transfers before running them.
+//
+// Fields:
+//   - Chunks:        chunks to send, in source order, each listed once
+//   - TotalBytes:    bytes to send (sum of StoredSize of Chunks)
+//   - SkippedChunks: source chunks already present, repeated, or all-zero
+//   - SkippedBytes:  logical bytes covered by SkippedChunks
+//   - BytesPerSec:   bandwidth the estimate is based on (0 if none)
+//   - Estimated:     expected duration at BytesPerSec (0 if unknown)
+type TransferPlan struct {
+	Chunks        []types.Chunk
+	TotalBytes    int64
+	SkippedChunks int
+	SkippedBytes  int64
+	BytesPerSec   int64
+	Estimated     time.Duration
+}
+
+// PlanTransfer computes the TransferPlan for sending the chunks in src to a
+// destination described by dest. bytesPerSec is the expected bandwidth used
+// for the time estimate; pass 0 to skip estimation.
+//
+// If dest implements PersistentIndex, lookup errors are returned instead of
+// being treated as missing chunks.
+func PlanTransfer(src []types.Chunk, dest Index, bytesPerSec int64) (TransferPlan, error) {
+	plan := TransferPlan{BytesPerSec: bytesPerSec}
+	persistent, _ := dest.(PersistentIndex)
+	planned := make(map[string]struct{})
+
+	for _, ch := range src {
+		key := ch.HexHash()
+		if _, ok := planned[key]; ok || ch.Zero {
+			plan.SkippedChunks++
+			plan.SkippedBytes += int64(ch.Size)
+			continue
+		}
+
+		var exists bool
+		if persistent != nil {
+			ok, err := persistent.ExistsWithErr(key)
+			if err != nil {
+				return TransferPlan{}, fmt.Errorf("check %s in destination: %w", key, err)
+			}
+			exists = ok
+		} else {
+			exists = dest.Exists(key)
+		}
+
+		planned[key] = struct{}{}
+		if exists {
+			plan.SkippedChunks++
+			plan.SkippedBytes += int64(ch.Size)
+			continue
+		}
+
+		plan.Chunks = append(plan.Chunks, ch)
+		plan.TotalBytes += int64(ch.StoredSize())
+	}
+
+	if bytesPerSec > 0 {
+		plan.Estimated = time.Duration(float64(plan.TotalBytes) / float64(bytesPerSec) * float64(time.Second))
+	}
+
+	return plan, nil
+}
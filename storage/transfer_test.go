@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// TestPlanTransfer verifies that chunks already at the destination, repeated
+// chunks, and zero chunks are skipped, and that the estimate follows the
+// bandwidth.
+func TestPlanTransfer(t *testing.T) {
+	present := helperChunk([]byte("present"), 1000)
+	missing := helperChunk([]byte("missing"), 3000)
+	zero := helperChunk(make([]byte, 8), 500)
+	zero.Zero = true
+
+	dest := NewMemoryIndex()
+	_ = dest.Add(present)
+
+	src := []types.Chunk{present, missing, zero, missing}
+	plan, err := PlanTransfer(src, dest, 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(plan.Chunks) != 1 || plan.Chunks[0].HexHash() != missing.HexHash() {
+		t.Fatalf("planned chunks = %v, want only the missing chunk", plan.Chunks)
+	}
+	if plan.TotalBytes != 3000 {
+		t.Errorf("total bytes = %d, want 3000", plan.TotalBytes)
+	}
+	if plan.SkippedChunks != 3 || plan.SkippedBytes != 1000+500+3000 {
+		t.Errorf("skipped = %d chunks / %d bytes", plan.SkippedChunks, plan.SkippedBytes)
+	}
+	if plan.Estimated != 3*time.Second {
+		t.Errorf("estimate = %v, want 3s", plan.Estimated)
+	}
+
+	// Plans must survive a JSON round-trip
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var decoded TransferPlan
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if decoded.TotalBytes != plan.TotalBytes || len(decoded.Chunks) != 1 {
+		t.Errorf("round-trip mismatch: %+v", decoded)
+	}
+}
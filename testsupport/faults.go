@@ -0,0 +1,251 @@
+// Package testsupport provides fault-injecting wrappers around indices,
+// readers, and writers, so code built on cdcgo can be tested against
+// latency, transient errors, corruption, and partial writes.
+package testsupport
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/AumSahayata/cdcgo/storage"
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// ErrInjected is the default error returned by injected failures.
+var ErrInjected = errors.New("injected fault")
+
+// Faults configures which failures an Injector produces. Rates are
+// probabilities in [0, 1] applied per operation.
+//
+// Fields:
+//   - Latency:     delay added before every operation
+//   - ErrorRate:   probability that an operation fails with Err
+//   - CorruptRate: probability that data passing through is corrupted
+//   - PartialRate: probability that a write stores only part of its data
+//   - Err:         error returned by failures (defaults to ErrInjected)
+//   - Seed:        seed for the fault sequence, so runs are reproducible
+type Faults struct {
+	Latency     time.Duration
+	ErrorRate   float64
+	CorruptRate float64
+	PartialRate float64
+	Err         error
+	Seed        uint64
+}
+
+// Injector decides when faults happen. A single Injector may be shared by
+// several wrappers so they fail together.
+//
+// Concurrency:
+//   - Safe for concurrent use.
+//   - Set and FailNext may be called while operations are running.
+type Injector struct {
+	faults   Faults
+	rng      *rand.Rand
+	failNext int // operations that fail unconditionally
+	mu       sync.Mutex
+}
+
+// NewInjector creates an Injector producing the given faults.
+func NewInjector(f Faults) *Injector {
+	return &Injector{faults: f, rng: rand.New(rand.NewPCG(f.Seed, f.Seed))}
+}
+
+// Set replaces the fault configuration. The random sequence is not reseeded.
+func (in *Injector) Set(f Faults) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	in.faults = f
+}
+
+// FailNext makes the next n operations fail regardless of ErrorRate.
+func (in *Injector) FailNext(n int) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	in.failNext = n
+}
+
+// delay sleeps for the configured latency.
+func (in *Injector) delay() {
+	in.mu.Lock()
+	d := in.faults.Latency
+	in.mu.Unlock()
+
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// fail returns a non-nil error if this operation should fail.
+func (in *Injector) fail() error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if in.failNext > 0 {
+		in.failNext--
+		return in.err()
+	}
+	if in.roll(in.faults.ErrorRate) {
+		return in.err()
+	}
+	return nil
+}
+
+// corrupt flips one random bit of p if corruption is due.
+func (in *Injector) corrupt(p []byte) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if len(p) == 0 || !in.roll(in.faults.CorruptRate) {
+		return
+	}
+	i := in.rng.IntN(len(p))
+	p[i] ^= 1 << in.rng.IntN(8)
+}
+
+// partial returns how many of n bytes a write should store: n, or a
+// random shorter length if a partial write is due.
+func (in *Injector) partial(n int) int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if n == 0 || !in.roll(in.faults.PartialRate) {
+		return n
+	}
+	return in.rng.IntN(n)
+}
+
+// roll reports whether an event with probability p happens. Callers must
+// hold in.mu.
+func (in *Injector) roll(p float64) bool {
+	return p > 0 && in.rng.Float64() < p
+}
+
+// err returns the configured failure error. Callers must hold in.mu.
+func (in *Injector) err() error {
+	if in.faults.Err != nil {
+		return in.faults.Err
+	}
+	return ErrInjected
+}
+
+// Writer is an io.Writer that injects latency, errors, corruption, and
+// partial writes. Partial writes return io.ErrShortWrite.
+type Writer struct {
+	w  io.Writer
+	in *Injector
+}
+
+// NewWriter wraps w with faults from in.
+func NewWriter(w io.Writer, in *Injector) *Writer {
+	return &Writer{w: w, in: in}
+}
+
+// Write writes p to the underlying writer, subject to injected faults.
+// The caller's buffer is never modified.
+func (fw *Writer) Write(p []byte) (int, error) {
+	fw.in.delay()
+	if err := fw.in.fail(); err != nil {
+		return 0, err
+	}
+
+	buf := append([]byte(nil), p...)
+	fw.in.corrupt(buf)
+
+	n := fw.in.partial(len(buf))
+	written, err := fw.w.Write(buf[:n])
+	if err != nil {
+		return written, err
+	}
+	if n < len(p) {
+		return written, io.ErrShortWrite
+	}
+	return written, nil
+}
+
+// Reader is an io.Reader that injects latency, errors, and corruption.
+type Reader struct {
+	r  io.Reader
+	in *Injector
+}
+
+// NewReader wraps r with faults from in.
+func NewReader(r io.Reader, in *Injector) *Reader {
+	return &Reader{r: r, in: in}
+}
+
+// Read reads from the underlying reader, subject to injected faults.
+func (fr *Reader) Read(p []byte) (int, error) {
+	fr.in.delay()
+	if err := fr.in.fail(); err != nil {
+		return 0, err
+	}
+
+	n, err := fr.r.Read(p)
+	fr.in.corrupt(p[:n])
+	return n, err
+}
+
+// Index wraps a storage.Index and implements storage.PersistentIndex with
+// injected latency and errors. Add, ExistsWithErr, and GetWithErr may fail;
+// Exists and Get report a failed lookup as a miss.
+type Index struct {
+	idx storage.Index
+	in  *Injector
+}
+
+// NewIndex wraps idx with faults from in.
+func NewIndex(idx storage.Index, in *Injector) *Index {
+	return &Index{idx: idx, in: in}
+}
+
+// Add records chunk in the underlying index unless a fault is injected.
+func (fi *Index) Add(chunk types.Chunk) error {
+	fi.in.delay()
+	if err := fi.in.fail(); err != nil {
+		return err
+	}
+	return fi.idx.Add(chunk)
+}
+
+// Exists reports whether hash exists; injected errors read as false.
+func (fi *Index) Exists(hash string) bool {
+	ok, _ := fi.ExistsWithErr(hash)
+	return ok
+}
+
+// Get returns chunk metadata; injected errors read as not found.
+func (fi *Index) Get(hash string) (types.Chunk, bool) {
+	ch, ok, _ := fi.GetWithErr(hash)
+	return ch, ok
+}
+
+// ExistsWithErr reports whether hash exists, or an injected error.
+func (fi *Index) ExistsWithErr(hash string) (bool, error) {
+	fi.in.delay()
+	if err := fi.in.fail(); err != nil {
+		return false, err
+	}
+	if p, ok := fi.idx.(storage.PersistentIndex); ok {
+		return p.ExistsWithErr(hash)
+	}
+	return fi.idx.Exists(hash), nil
+}
+
+// GetWithErr returns chunk metadata, or an injected error.
+func (fi *Index) GetWithErr(hash string) (types.Chunk, bool, error) {
+	fi.in.delay()
+	if err := fi.in.fail(); err != nil {
+		return types.Chunk{}, false, err
+	}
+	if p, ok := fi.idx.(storage.PersistentIndex); ok {
+		return p.GetWithErr(hash)
+	}
+	ch, ok := fi.idx.Get(hash)
+	return ch, ok, nil
+}
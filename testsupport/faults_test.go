@@ -0,0 +1,87 @@
+package testsupport
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/AumSahayata/cdcgo/storage"
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// TestWriter_Faults checks FailNext, partial writes, and corruption.
+func TestWriter_Faults(t *testing.T) {
+	var buf bytes.Buffer
+	in := NewInjector(Faults{Seed: 1})
+	w := NewWriter(&buf, in)
+	data := bytes.Repeat([]byte("x"), 64)
+
+	in.FailNext(1)
+	if _, err := w.Write(data); !errors.Is(err, ErrInjected) {
+		t.Fatalf("err = %v, want ErrInjected", err)
+	}
+	if n, err := w.Write(data); err != nil || n != len(data) {
+		t.Fatalf("clean write = (%d, %v)", n, err)
+	}
+
+	in.Set(Faults{PartialRate: 1})
+	if n, err := w.Write(data); err != io.ErrShortWrite || n >= len(data) {
+		t.Fatalf("partial write = (%d, %v), want short write", n, err)
+	}
+
+	buf.Reset()
+	in.Set(Faults{CorruptRate: 1})
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(buf.Bytes(), data) {
+		t.Error("write was not corrupted")
+	}
+	if !bytes.Equal(data, bytes.Repeat([]byte("x"), 64)) {
+		t.Error("caller's buffer was modified")
+	}
+}
+
+// TestReader_Corrupt checks that corrupted reads differ from the source.
+func TestReader_Corrupt(t *testing.T) {
+	data := bytes.Repeat([]byte("y"), 256)
+	r := NewReader(bytes.NewReader(data), NewInjector(Faults{CorruptRate: 1, Seed: 2}))
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(data) || bytes.Equal(got, data) {
+		t.Error("expected same-length corrupted data")
+	}
+}
+
+// TestIndex_Faults checks that injected index errors surface through the
+// error-returning methods and read as misses otherwise.
+func TestIndex_Faults(t *testing.T) {
+	in := NewInjector(Faults{})
+	idx := NewIndex(storage.NewMemoryIndex(), in)
+	ch := types.Chunk{Hash: []byte{0xab}, Size: 1}
+
+	in.FailNext(1)
+	if err := idx.Add(ch); !errors.Is(err, ErrInjected) {
+		t.Fatalf("Add err = %v, want ErrInjected", err)
+	}
+	if err := idx.Add(ch); err != nil {
+		t.Fatal(err)
+	}
+
+	in.FailNext(2)
+	if _, err := idx.ExistsWithErr("ab"); !errors.Is(err, ErrInjected) {
+		t.Errorf("ExistsWithErr err = %v, want ErrInjected", err)
+	}
+	if idx.Exists("ab") {
+		t.Error("Exists should report a miss on injected failure")
+	}
+	if !idx.Exists("ab") {
+		t.Error("Exists should succeed once faults are exhausted")
+	}
+
+	var _ storage.PersistentIndex = idx
+}
@@ -12,10 +12,53 @@ import (
 //   - Offset: byte offset of the chunk within the original input
 //   - Size:   length of the chunk in bytes
 //   - Hash:   cryptographic hash (e.g., SHA-256) of the chunk’s data
+//   - Zero:   true if every byte of the chunk is 0x00; such chunks carry
+//     no stored data and are restored as holes in sparse files
+//
+// Optional storage metadata, for compressed or encrypted repositories:
+//   - CompressedSize: length of the stored (compressed) data, 0 if stored raw
+//   - Codec:          compression codec name (e.g. "zstd"), empty if none
+//   - Nonce:          encryption nonce/IV used for the stored data
+//   - Flags:          bit set describing how the stored data is encoded
+//
+// Hash, Size, and Offset always describe the original, uncompressed and
+// unencrypted data. The optional fields are omitted from JSON when unset,
+// so indices written before they existed remain readable.
 type Chunk struct {
 	Offset int64
 	Size   int
 	Hash   []byte
+	Zero   bool `json:",omitempty"`
+
+	CompressedSize int        `json:",omitempty"`
+	Codec          string     `json:",omitempty"`
+	Nonce          []byte     `json:",omitempty"`
+	Flags          ChunkFlags `json:",omitempty"`
+}
+
+// ChunkFlags describes how a chunk's stored data is encoded.
+type ChunkFlags uint32
+
+const (
+	FlagCompressed ChunkFlags = 1 << iota // stored data is compressed with Codec
+	FlagEncrypted                         // stored data is encrypted; see Nonce
+)
+
+// Has reports whether all bits in flag are set.
+func (f ChunkFlags) Has(flag ChunkFlags) bool {
+	return f&flag == flag
+}
+
+// StoredSize returns the number of bytes the chunk occupies in storage:
+// CompressedSize if set, otherwise Size. Zero chunks occupy no space.
+func (c Chunk) StoredSize() int {
+	if c.Zero {
+		return 0
+	}
+	if c.CompressedSize > 0 {
+		return c.CompressedSize
+	}
+	return c.Size
 }
 
 // HexHash returns the hash in hex string form.
@@ -0,0 +1,160 @@
+// Package vectors publishes deterministic conformance test vectors for the
+// default FastCDC gear table: fixed inputs, parameters, and the expected
+// chunk boundaries and hashes.
+//
+// Alternative implementations and future refactors can prove boundary
+// compatibility by running RunConformance against their chunker.
+package vectors
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/AumSahayata/cdcgo/fastcdc"
+)
+
+// Vector is one conformance case.
+//
+// Fields:
+//   - Name:    short identifier
+//   - Input:   generator for the input bytes (deterministic)
+//   - Min, Avg, Max: chunk size parameters, passed to fastcdc.NewParams
+//     with the default gear table
+//   - Sizes:   expected chunk sizes, in order
+//   - Digest:  hex SHA-256 over the concatenated SHA-256 of every chunk
+type Vector struct {
+	Name   string
+	Input  func() []byte
+	Min    int
+	Avg    int
+	Max    int
+	Sizes  []int
+	Digest string
+}
+
+// Params returns the chunking parameters of the vector.
+func (v Vector) Params() fastcdc.Params {
+	return fastcdc.NewParams(v.Min, v.Avg, v.Max, nil)
+}
+
+// SplitFunc splits data into chunks and returns their sizes in order.
+type SplitFunc func(data []byte, params fastcdc.Params) []int
+
+// DefaultSplit splits data with fastcdc.Chunker.
+func DefaultSplit(data []byte, params fastcdc.Params) []int {
+	c := fastcdc.NewChunker(params)
+
+	var sizes []int
+	for off := 0; off < len(data); {
+		cut := c.NextBoundary(data[off:])
+		sizes = append(sizes, cut)
+		off += cut
+	}
+	return sizes
+}
+
+// Digest returns the vector digest of data split into sizes.
+func Digest(data []byte, sizes []int) string {
+	h := sha256.New()
+	off := 0
+	for _, n := range sizes {
+		sum := sha256.Sum256(data[off : off+n])
+		h.Write(sum[:])
+		off += n
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RunConformance runs split against every vector in Vectors and returns an
+// error describing each mismatch, or nil if all vectors pass.
+func RunConformance(split SplitFunc) error {
+	var errs []error
+	for _, v := range Vectors {
+		if err := v.Check(split); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Check runs split against this vector.
+func (v Vector) Check(split SplitFunc) error {
+	data := v.Input()
+	sizes := split(data, v.Params())
+
+	if !slices.Equal(sizes, v.Sizes) {
+		return fmt.Errorf("vector %s: chunk sizes %v, want %v", v.Name, sizes, v.Sizes)
+	}
+	if got := Digest(data, sizes); got != v.Digest {
+		return fmt.Errorf("vector %s: digest %s, want %s", v.Name, got, v.Digest)
+	}
+	return nil
+}
+
+// xorshift returns n pseudo-random bytes from a xorshift64* generator.
+// It is defined here, rather than using math/rand, so the inputs can never
+// change underneath the vectors.
+func xorshift(seed uint64, n int) []byte {
+	out := make([]byte, n)
+	x := seed
+	for i := range out {
+		x ^= x >> 12
+		x ^= x << 25
+		x ^= x >> 27
+		out[i] = byte((x * 0x2545f4914f6cdd1d) >> 56)
+	}
+	return out
+}
+
+// Vectors is the published set of conformance vectors.
+var Vectors = []Vector{
+	{
+		Name:   "random-64k",
+		Input:  func() []byte { return xorshift(1, 64<<10) },
+		Min:    1 << 10,
+		Avg:    4 << 10,
+		Max:    16 << 10,
+		Sizes:  []int{16384, 4238, 9161, 16384, 13364, 6005},
+		Digest: "a669022cf858c4cb052322229b76291eae1f1f9ad1cda2ef0f6e1cad6f6f8035",
+	},
+	{
+		Name:   "random-256k-large",
+		Input:  func() []byte { return xorshift(2, 256<<10) },
+		Min:    8 << 10,
+		Avg:    32 << 10,
+		Max:    128 << 10,
+		Sizes:  []int{69838, 57686, 33151, 36477, 37689, 27303},
+		Digest: "bef2c5eab40c38f7e3ef0d01f177c964388ea10e40f07241a1e6b628a800314b",
+	},
+	{
+		Name:   "zeros-32k",
+		Input:  func() []byte { return make([]byte, 32<<10) },
+		Min:    1 << 10,
+		Avg:    4 << 10,
+		Max:    16 << 10,
+		Sizes:  []int{16384, 16384},
+		Digest: "c36d0dd6a886e1fce758b6b5c531b703a1f21e8f6453785c390931cf8fa8a76d",
+	},
+	{
+		Name:   "text-48k",
+		Input:  func() []byte { return bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog\n"), 48<<10/44) },
+		Min:    512,
+		Avg:    2 << 10,
+		Max:    8 << 10,
+		Sizes:  []int{8192, 8192, 8192, 8192, 8192, 8188},
+		Digest: "c652f74b927dd0c7ba1493bdfe62e1f020caefb24d0caa1634123b4d4e67a215",
+	},
+	{
+		Name:   "tiny",
+		Input:  func() []byte { return xorshift(3, 100) },
+		Min:    1 << 10,
+		Avg:    4 << 10,
+		Max:    16 << 10,
+		Sizes:  []int{100},
+		Digest: "77e7e1ad7e2f3b6c7ec0aac6108767a0bbd518b77c0f1ccfb0426bdd2249a063",
+	},
+}
@@ -0,0 +1,53 @@
+package vectors
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"testing"
+
+	"github.com/AumSahayata/cdcgo/chunk"
+	"github.com/AumSahayata/cdcgo/fastcdc"
+	"github.com/AumSahayata/cdcgo/types"
+)
+
+// TestConformance_Default verifies that fastcdc.Chunker matches the vectors.
+func TestConformance_Default(t *testing.T) {
+	if err := RunConformance(DefaultSplit); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestConformance_Ingestor verifies that the push-style chunk.Ingestor
+// produces the same boundaries as the vectors.
+func TestConformance_Ingestor(t *testing.T) {
+	split := func(data []byte, params fastcdc.Params) []int {
+		var sizes []int
+		in := chunk.NewIngestor(sha256.New(), params.MaxSize, fastcdc.NewChunker(params), func(ch types.Chunk, _ []byte) error {
+			sizes = append(sizes, ch.Size)
+			return nil
+		})
+		_, _ = io.Copy(in, bytes.NewReader(data))
+		_ = in.Flush()
+		return sizes
+	}
+
+	if err := RunConformance(split); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestConformance_DetectsMismatch verifies that a different chunker fails.
+func TestConformance_DetectsMismatch(t *testing.T) {
+	fixed := func(data []byte, _ fastcdc.Params) []int {
+		var sizes []int
+		for off := 0; off < len(data); off += 4096 {
+			sizes = append(sizes, min(4096, len(data)-off))
+		}
+		return sizes
+	}
+
+	if err := RunConformance(fixed); err == nil {
+		t.Errorf("expected fixed-size chunker to fail conformance")
+	}
+}